@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/devops-works/binenv/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// bundleCmd creates and imports bundles for offline machines
+func bundleCmd(a *app.App) *cobra.Command {
+	var (
+		lockfile, output string
+		platforms        []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Export and import bundles for offline machines",
+		Long: `A bundle packs distributions definitions, versions and downloaded artifacts
+required by a .binenv.lock file, so "binenv install --lock" can run on
+machines without internet access.`,
+	}
+
+	export := &cobra.Command{
+		Use:   "export [--lock <file>] [--platform <os/arch>] -o <bundle>",
+		Short: "Export versions required by a lock file to a bundle",
+		Long: `Export distributions and versions required by a lock file to a bundle.
+Artifacts are downloaded for the current platform, unless one or several
+--platform are given.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.BundleExport(cmd.Context(), lockfile, output, platforms)
+		},
+	}
+
+	export.Flags().StringVarP(&lockfile, "lock", "l", ".binenv.lock", "Lock file listing distributions to export")
+	export.Flags().StringVarP(&output, "output", "o", "", "Bundle file to create")
+	export.Flags().StringSliceVarP(&platforms, "platform", "p", nil, "Platform to export artifacts for, as os/arch (can be repeated)")
+	export.MarkFlagRequired("output")
+
+	cmd.AddCommand(
+		export,
+		&cobra.Command{
+			Use:   "import <bundle>",
+			Short: "Import a bundle",
+			Long: `Import distributions definitions, versions and artifacts from a bundle.
+Nothing is fetched from the network.`,
+			Args:         cobra.ExactArgs(1),
+			SilenceUsage: true,
+			Annotations: map[string]string{
+				localDistributionsAnnotation: "true",
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return a.BundleImport(args[0])
+			},
+		},
+	)
+
+	return cmd
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/devops-works/binenv/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd manages downloaded artifacts
+func cacheCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage downloaded artifacts cache",
+		Long: `Downloaded artifacts are kept in the cache directory so reinstalling a
+version, or installing distributions sharing the same artifact, does not
+download it again.`,
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "ls",
+			Short: "List cached artifacts",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return a.CacheList()
+			},
+		},
+		&cobra.Command{
+			Use:   "size",
+			Short: "Show disk space used by cached artifacts",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return a.CacheSize()
+			},
+		},
+		&cobra.Command{
+			Use:   "clean",
+			Short: "Remove all cached artifacts",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return a.CacheClean()
+			},
+		},
+	)
+
+	return cmd
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/devops-works/binenv/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// distributionsCmd helps writing distributions definitions
+func distributionsCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "distributions",
+		Short: "Help writing distributions definitions",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "test-version <distribution> <tag>",
+			Short: "Show how a tag is turned into a version",
+			Long: `Apply the distribution exclude, prefix and version_regex settings to a tag
+(or release name when version_from is "name"), and show the resulting version.
+The command fails when the tag would not be listed.`,
+			Example:      "  binenv distributions test-version kustomize kustomize/v5.3.0",
+			Args:         cobra.ExactArgs(2),
+			SilenceUsage: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return a.TestVersion(args[0], args[1])
+			},
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+				if len(args) > 0 {
+					return nil, cobra.ShellCompDirectiveNoFileComp
+				}
+				return a.GetPackagesListWithPrefix(toComplete), cobra.ShellCompDirectiveNoFileComp
+			},
+		},
+	)
+
+	return cmd
+}
@@ -9,7 +9,8 @@ import (
 
 // localCmd represents the local command
 func installCmd(a *app.App) *cobra.Command {
-	var fromlock, dryrun bool
+	var fromlock, dryrun, skipVerify bool
+	var concurrency int
 
 	cmd := &cobra.Command{
 		Use:   "install [--lock] [--dry-run] [<distribution> <version> [<distribution> <version>]]",
@@ -22,13 +23,15 @@ If --lock is used, versions from the .binenv.lock file in the current directory
 				os.Exit(1)
 			}
 			a.SetDryRun(dryrun)
+			a.SetInsecureSkipVerify(skipVerify)
+			a.SetConcurrency(concurrency)
 
 			if fromlock {
-				a.InstallFromLock()
+				a.InstallFromLock(cmd.Context())
 				return
 			}
 
-			a.Install(args...)
+			a.Install(cmd.Context(), args...)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			switch len(args) % 2 {
@@ -47,6 +50,8 @@ If --lock is used, versions from the .binenv.lock file in the current directory
 
 	cmd.Flags().BoolVarP(&fromlock, "lock", "l", false, "Install versions specified in ./.binenv.lock")
 	cmd.Flags().BoolVarP(&dryrun, "dry-run", "n", false, "Do not install, just simulate")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "c", 8, "Concurrency for installs")
+	cmd.Flags().BoolVar(&skipVerify, "insecure-skip-verify", false, "Do not verify downloaded files against published checksums and signatures")
 
 	return cmd
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/devops-works/binenv/internal/app"
+	"github.com/devops-works/binenv/internal/httpclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -14,6 +15,10 @@ import (
 const (
 	// The environment variable prefix of all environment variables bound to our command line flags.
 	envPrefix = "BINENV"
+
+	// Commands with this annotation set to "true" do not fetch distributions
+	// when none are available locally
+	localDistributionsAnnotation = "binenv.local-distributions"
 )
 
 // RootCmd returns the root cobra command
@@ -21,6 +26,7 @@ func RootCmd() *cobra.Command {
 	var (
 		bindir, linkdir, cachedir, confdir string
 		global, verbose                    bool
+		httpConf                           httpclient.Config
 	)
 
 	a, err := app.New()
@@ -47,6 +53,8 @@ This is version ` + Version + ` built on ` + BuildDate + `.`,
 			}
 
 			a.SetVerbose(verbose)
+			a.SetVersion(Version)
+			a.SetHTTPConfig(httpConf)
 
 			// Set defaults or explicitely set directories
 			a.SetBinDir(bindir)
@@ -78,7 +86,12 @@ This is version ` + Version + ` built on ` + BuildDate + `.`,
 				a.SetCacheDir(cachedir)
 			}
 
-			err = a.Init()
+			opts := []func(*app.App) error{}
+			if cmd.Annotations[localDistributionsAnnotation] == "true" {
+				opts = append(opts, app.WithLocalDistributions())
+			}
+
+			err = a.Init(opts...)
 			if err != nil {
 				os.Exit(0)
 				return err
@@ -137,6 +150,14 @@ This is version ` + Version + ` built on ` + BuildDate + `.`,
 	rootCmd.PersistentFlags().StringVarP(&cachedir, "cachedir", "K", dcache, "cache directory [BINENV_CACHEDIR]")
 	rootCmd.PersistentFlags().StringVarP(&confdir, "confdir", "C", dconf, "distributions configuration directory [BINENV_CONFDIR]")
 
+	rootCmd.PersistentFlags().StringVar(&httpConf.Proxy, "proxy", "", "proxy URL for all requests; defaults to HTTP(S)_PROXY [BINENV_PROXY]")
+	rootCmd.PersistentFlags().StringVar(&httpConf.NoProxy, "no-proxy", "", "comma separated hosts not using --proxy [BINENV_NO_PROXY]")
+	rootCmd.PersistentFlags().StringVar(&httpConf.CABundle, "ca-bundle", "", "PEM file with additional trusted CA certificates [BINENV_CA_BUNDLE]")
+	rootCmd.PersistentFlags().StringVar(&httpConf.ClientCert, "client-cert", "", "PEM client certificate for TLS authentication [BINENV_CLIENT_CERT]")
+	rootCmd.PersistentFlags().StringVar(&httpConf.ClientKey, "client-key", "", "PEM client key for TLS authentication [BINENV_CLIENT_KEY]")
+	rootCmd.PersistentFlags().DurationVar(&httpConf.Timeout, "http-timeout", 0, "timeout for connections and response headers (default 30s) [BINENV_HTTP_TIMEOUT]")
+	rootCmd.PersistentFlags().BoolVar(&httpConf.Trace, "http-trace", false, "log HTTP requests and connections [BINENV_HTTP_TRACE]")
+
 	// disable flag parsing if we're called as a shim
 	if !isItMe() {
 		debugCompletion("binenv called in shim mode for %q\n", strings.Join(os.Args, " "))
@@ -152,7 +173,10 @@ This is version ` + Version + ` built on ` + BuildDate + `.`,
 	debugCompletion("binenv called in binenv mode for %q\n", strings.Join(os.Args, " "))
 
 	rootCmd.AddCommand(
+		bundleCmd(a),
+		cacheCmd(a),
 		completionCmd(),
+		distributionsCmd(a),
 		expandCmd(a),
 		installCmd(a),
 		localCmd(a),
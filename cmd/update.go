@@ -8,8 +8,9 @@ import (
 // localCmd represents the local command
 func updateCmd(a *app.App) *cobra.Command {
 	var (
-		distributionsOnly, distributionsAlso, noCache bool
-		concurrency                                   int
+		distributionsOnly, distributionsAlso, noCache, wait, full bool
+		concurrency                                               int
+		source                                                    string
 	)
 
 	cmd := &cobra.Command{
@@ -17,14 +18,16 @@ func updateCmd(a *app.App) *cobra.Command {
 		Short: "Update available software distributions",
 		Long: `Available versions listed distribution will be updated.
 If not distribution is specified, versions for all distributions will be updated.`,
-		Run: func(cmd *cobra.Command, args []string) {
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			a.SetConcurrency(concurrency)
-
-			if len(args) >= 1 {
-				a.Update(distributionsOnly, distributionsAlso, noCache, args...)
-				return
+			a.SetWaitRateLimit(wait)
+			a.SetFullUpdate(full)
+			if err := a.SetUpdateSource(source); err != nil {
+				return err
 			}
-			a.Update(distributionsOnly, distributionsAlso, noCache)
+
+			return a.Update(distributionsOnly, distributionsAlso, noCache, args...)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			// Remove already selected distributions from completion
@@ -36,8 +39,11 @@ If not distribution is specified, versions for all distributions will be updated
 
 	cmd.Flags().BoolVarP(&distributionsOnly, "distributions", "d", false, "Update only distributions")
 	cmd.Flags().BoolVarP(&distributionsAlso, "all", "a", false, "Update distributions and distributions versions")
-	cmd.Flags().BoolVarP(&noCache, "nocache", "f", false, "Distributions versions will be updated from each release and not from github cache")
+	cmd.Flags().BoolVarP(&noCache, "nocache", "f", false, "Distributions versions will be updated from each release and not from github cache (same as --source local)")
+	cmd.Flags().StringVar(&source, "source", app.SourceRemote, "Where to get versions from: remote (github cache), local (each release) or auto (github cache, then releases of distributions missing from it)")
 	cmd.Flags().IntVarP(&concurrency, "concurrency", "c", 8, "Concurrency for cache update")
+	cmd.Flags().BoolVarP(&wait, "wait", "w", false, "Wait for rate limits to reset instead of stopping (with local or auto source)")
+	cmd.Flags().BoolVar(&full, "full", false, "List all releases instead of only new ones (with local or auto source)")
 	return cmd
 }
 
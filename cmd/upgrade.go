@@ -7,18 +7,23 @@ import (
 
 // upgradeCmd upgrade all installed distributions
 func upgradeCmd(a *app.App) *cobra.Command {
-	var ignoreInstallErrors bool
+	var ignoreInstallErrors, skipVerify bool
+	var concurrency int
 
 	cmd := &cobra.Command{
 		Use:   "upgrade",
 		Short: "Upgrade all installed distributions",
 		Long:  `Upgrade all installed distributions to the last version available on cache.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			a.Upgrade(ignoreInstallErrors)
+			a.SetInsecureSkipVerify(skipVerify)
+			a.SetConcurrency(concurrency)
+			a.Upgrade(cmd.Context(), ignoreInstallErrors)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&ignoreInstallErrors, "ignore-install-errors", "i", true, "Ignore install errors during upgrade")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "c", 8, "Concurrency for upgrades")
+	cmd.Flags().BoolVar(&skipVerify, "insecure-skip-verify", false, "Do not verify downloaded files against published checksums and signatures")
 
 	return cmd
 }
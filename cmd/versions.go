@@ -7,10 +7,10 @@ import (
 
 // versionsCmd lists installable versions as seen from cache
 func versionsCmd(a *app.App) *cobra.Command {
-	var freeze bool
+	var freeze, wide bool
 
 	cmd := &cobra.Command{
-		Use:   "versions [distribution...] [--freeze]",
+		Use:   "versions [distribution...] [--freeze|--wide]",
 		Short: "List installable versions",
 		Long: `List all installable versions for a distribution.
 If the distribution is not specified, lists all available version for all distributions.
@@ -20,10 +20,14 @@ Versions installed locally have a '+'.
 
 The --freeze (-f) argument will output a list of currently selected distribution versions on stdout.
 
+The --wide (-w) argument lists versions with release metadata (release date,
+prerelease flag, number of assets and release page) when the source provides
+them.
+
 Use 'binenv update' to update the list of available versions.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			freeze, _ := cmd.Flags().GetBool("freeze")
-			a.Versions(freeze, args...)
+			a.Versions(freeze, wide, args...)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return a.GetPackagesListWithPrefix(toComplete), cobra.ShellCompDirectiveNoFileComp
@@ -31,6 +35,7 @@ Use 'binenv update' to update the list of available versions.`,
 	}
 
 	cmd.Flags().BoolVarP(&freeze, "freeze", "f", false, "Write a .binenv.lock file to stdout containing currently selected versions")
+	cmd.Flags().BoolVarP(&wide, "wide", "w", false, "Show release metadata")
 
 	return cmd
 }
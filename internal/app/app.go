@@ -3,17 +3,21 @@ package app
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	gov "github.com/hashicorp/go-version"
@@ -22,13 +26,18 @@ import (
 	"github.com/schollz/progressbar/v3"
 	"gopkg.in/yaml.v2"
 
+	"github.com/devops-works/binenv/internal/auth"
 	"github.com/devops-works/binenv/internal/fetch"
+	"github.com/devops-works/binenv/internal/httpcache"
+	"github.com/devops-works/binenv/internal/httpclient"
 	"github.com/devops-works/binenv/internal/install"
 	"github.com/devops-works/binenv/internal/list"
+	"github.com/devops-works/binenv/internal/store"
 
 	"github.com/logrusorgru/aurora"
 
 	"github.com/devops-works/binenv/internal/mapping"
+	"github.com/devops-works/binenv/internal/platform"
 )
 
 // Where to fetch distribution list and cached version
@@ -39,12 +48,17 @@ import (
 const (
 	distributionsURL = "https://raw.githubusercontent.com/devops-works/binenv/master/distributions/distributions.yaml"
 	cacheURL         = "https://raw.githubusercontent.com/devops-works/binenv/develop/distributions/cache.json"
+	cacheV2URL       = "https://raw.githubusercontent.com/devops-works/binenv/develop/distributions/cache.v2.json"
 	globalBindir     = "/var/lib/binenv"
 	globalCachedir   = "/var/cache/binenv"
 	globalConfigdir  = "/var/lib/binenv/config"
 	globalLinkdir    = "/usr/local/bin"
 )
 
+// validatorsFile holds ETag and Last-Modified values of listed release pages
+// in the cache directory
+const validatorsFile = "etags.json"
+
 // App implements the core logic
 type App struct {
 	def        *Distributions
@@ -52,18 +66,41 @@ type App struct {
 	installers map[string]install.Installer
 	listers    map[string]list.Lister
 	fetchers   map[string]fetch.Fetcher
-	cache      map[string][]string
-	flags      flags
-
-	dryrun      bool
-	global      bool
-	concurrency int
+	cache      map[string][]list.Release
+	refreshed  map[string]time.Time
+	available  map[string]availability
+	// private holds distributions defined in files other than
+	// distributions.yaml and the bundle one, whose versions the remote cache
+	// does not replace
+	private     map[string]bool
+	artifacts   *store.Store
+	config      Config
+	httpConfig  httpclient.Config
+	client      *http.Client
+	credentials *auth.Resolver
+	validators  *httpcache.Cache
+	flags       flags
+
+	dryrun             bool
+	global             bool
+	insecureSkipVerify bool
+	localDistributions bool
+	concurrency        int
+	waitRateLimit      bool
+	fullUpdate         bool
+	updateSource       string
+
+	// availableMu guards available, updated while installing
+	availableMu      sync.Mutex
+	availableChanged bool
 
 	bindir    string
 	linkdir   string
 	cachedir  string
 	configdir string
 
+	version string
+
 	logger zerolog.Logger
 }
 
@@ -75,7 +112,10 @@ type flags struct {
 
 type jobResult struct {
 	distribution string
-	versions     []string
+	releases     []list.Release
+	// partial is set when only new releases were listed
+	partial bool
+	err     error
 }
 
 var (
@@ -83,6 +123,17 @@ var (
 	ErrAlreadyInstalled = errors.New("version already installed")
 )
 
+// Sources for distributions versions updates
+const (
+	// SourceRemote fetches versions from the published cache
+	SourceRemote = "remote"
+	// SourceLocal lists versions from each distribution releases
+	SourceLocal = "local"
+	// SourceAuto fetches versions from the published cache, and lists
+	// releases of distributions it lacks
+	SourceAuto = "auto"
+)
+
 // New creates a new App
 func New() (*App, error) {
 	a := &App{
@@ -90,7 +141,13 @@ func New() (*App, error) {
 		installers: make(map[string]install.Installer),
 		listers:    make(map[string]list.Lister),
 		fetchers:   make(map[string]fetch.Fetcher),
-		cache:      make(map[string][]string),
+		cache:      make(map[string][]list.Release),
+		refreshed:  make(map[string]time.Time),
+		available:  make(map[string]availability),
+		private:    make(map[string]bool),
+
+		updateSource: SourceRemote,
+
 		logger: zerolog.New(zerolog.ConsoleWriter{
 			Out:        os.Stderr,
 			TimeFormat: time.RFC3339,
@@ -113,22 +170,42 @@ func (a *App) Init(o ...func(*App) error) error {
 
 	a.DumpConfig()
 
-	err := a.readDistributions()
+	err := a.readConfig()
+	if err != nil {
+		a.logger.Error().Err(err).Msgf("unable to read configuration")
+		os.Exit(1)
+	}
+
+	err = a.readCredentials()
+	if err != nil {
+		a.logger.Error().Err(err).Msgf("unable to read credentials")
+		os.Exit(1)
+	}
+
+	err = a.createClient()
+	if err != nil {
+		a.logger.Error().Err(err).Msgf("unable to configure HTTP client")
+		os.Exit(1)
+	}
+
+	err = a.readDistributions()
 	if err != nil {
 		a.logger.Error().Err(err).Msgf("unable to read distributions")
 		os.Exit(1)
 	}
 
 	a.loadCache()
+	a.loadValidators()
+
+	var mode os.FileMode = 0750
+	if a.global {
+		mode = 0755
+	}
+	a.artifacts = store.New(filepath.Join(a.cachedir, "artifacts"), mode)
 
 	a.createMappers()
 	a.createListers()
-	_ = a.createFetchers()
-	// if err != nil {
-	// 	// No need to return; it would display binenv usage
-	// 	a.logger.Error().Err(err).Msgf("unable to create fetchers")
-	// 	os.Exit(1)
-	// }
+	a.createFetchers()
 	a.createInstallers()
 
 	a.initializeFlags()
@@ -188,13 +265,21 @@ func (a *App) GetPackagesListWithPrefix(pfix string) []string {
 	return res
 }
 
-// GetMostRecent returns the most recent stable available version
-func (a *App) GetMostRecent(dist string) string {
-	availVersions := a.GetAvailableVersionsFor(dist)
-	for _, v := range availVersions {
-		if gov.Must(gov.NewVersion(v)).Prerelease() == "" {
-			return v
+// mostRecentInstallable returns the most recent stable available version
+// that has a release for the running platform, probing releases if needed
+func (a *App) mostRecentInstallable(ctx context.Context, dist string) string {
+	logger := zerolog.Ctx(ctx)
+	installed := a.GetInstalledVersionsFor(dist)
+	p := platform.Current()
+	for _, v := range a.GetAvailableVersionsFor(dist) {
+		if gov.Must(gov.NewVersion(v)).Prerelease() != "" {
+			continue
 		}
+		if !stringInSlice(v, installed) && !a.probe(ctx, dist, v, p) {
+			logger.Warn().Msgf("skipping %q (%s): no release for %s", dist, v, p)
+			continue
+		}
+		return v
 	}
 	return ""
 }
@@ -240,7 +325,7 @@ func (a *App) GetAvailableVersionsFor(dist string) []string {
 		return []string{}
 	}
 
-	versions := a.cache[dist]
+	versions := list.Versions(a.cache[dist])
 	versionsV := make([]*gov.Version, len(versions))
 	for i, raw := range versions {
 		v, _ := gov.NewVersion(raw)
@@ -258,7 +343,7 @@ func (a *App) GetAvailableVersionsFor(dist string) []string {
 
 // InstallFromLock install distributions/versions to match the local
 // .binenv.lock file
-func (a *App) InstallFromLock() error {
+func (a *App) InstallFromLock(ctx context.Context) error {
 	// Get listed versions from local .binenv.lock
 	curdir, err := os.Getwd()
 	if err != nil {
@@ -269,34 +354,68 @@ func (a *App) InstallFromLock() error {
 
 	// Lets loop on each distribution and find the best versions among
 	// available versions
+	jobs := []installJob{}
+	results := []installResult{}
 	for i, d := range distributions {
-		available := a.GetAvailableVersionsFor(d)
-		required, _ := a.GuessBestVersionFor(d, curdir, curdir, available)
+		available := a.withoutUnavailable(d, a.GetAvailableVersionsFor(d))
 		installed := a.GetInstalledVersionsFor(d)
+		required := a.guessInstallable(ctx, d, curdir, available, installed)
 
 		if required == "" {
 			a.logger.Warn().Msgf(`no available versions found for %q. Please run "binenv update %s".`, d, d)
+			results = append(results, installResult{
+				dist:   d,
+				status: statusSkipped,
+				detail: "no available version",
+			})
 			continue
 		}
 		if !stringInSlice(required, installed) {
 			a.logger.Warn().Msgf("installing %q (%s) to satisfy constraint %q", d, required, lines[i])
-			a.install(d, required)
+			jobs = append(jobs, installJob{dist: d, version: required})
 		} else {
 			a.logger.Debug().Msgf("will use %q (%s) to satisfy constraint %q", d, required, lines[i])
 		}
 	}
 
+	results = append(results, a.installAll(ctx, jobs)...)
+	a.saveAvailability()
+	if len(results) > 1 {
+		printSummary(os.Stdout, results)
+	}
+
 	return nil
 }
 
+// guessInstallable returns the best version of dist for dir among
+// available ones, skipping versions found to have no release for the running
+// platform
+func (a *App) guessInstallable(ctx context.Context, dist, dir string, available, installed []string) string {
+	p := platform.Current()
+	for {
+		required, _ := a.GuessBestVersionFor(dist, dir, dir, available)
+		if required == "" || stringInSlice(required, installed) || a.probe(ctx, dist, required, p) {
+			return required
+		}
+
+		a.logger.Warn().Msgf("skipping %q (%s): no release for %s", dist, required, p)
+		n := len(available)
+		available = slices.DeleteFunc(available, func(v string) bool { return v == required })
+		if len(available) == n {
+			return ""
+		}
+	}
+}
+
 // Install installs or update a distribution
-func (a *App) Install(specs ...string) error {
+func (a *App) Install(ctx context.Context, specs ...string) error {
 	if len(specs)%2 != 0 && len(specs) != 1 {
 		a.logger.Error().Msg("invalid number of arguments (must have distribution and version pairs")
 		os.Exit(1)
 	}
 
-	errored := false
+	jobs := []installJob{}
+	results := []installResult{}
 
 	for i := 0; i < len(specs); i += 2 {
 		dist := specs[i]
@@ -316,25 +435,32 @@ func (a *App) Install(specs ...string) error {
 			}
 			if !isSupported {
 				a.logger.Error().Msgf("%q is not available for %s/%s", dist, runtime.GOOS, runtime.GOARCH)
-				errored = true
+				results = append(results, installResult{
+					dist:    dist,
+					version: version,
+					status:  statusFailed,
+					detail:  fmt.Sprintf("not available for %s/%s", runtime.GOOS, runtime.GOARCH),
+				})
 				continue
 			}
 		}
 
-		v, err := a.install(dist, version)
-		if err != nil && !errors.Is(err, ErrAlreadyInstalled) {
-			a.logger.Error().Err(err).Msgf("unable to install %q (%s)", dist, v)
+		jobs = append(jobs, installJob{dist: dist, version: version})
+	}
+
+	results = append(results, a.installAll(ctx, jobs)...)
+	a.saveAvailability()
+	if len(results) > 1 {
+		printSummary(os.Stdout, results)
+	}
+
+	errored := false
+	for _, r := range results {
+		if r.status == statusFailed {
 			errored = true
 			continue
 		}
-		if err == nil {
-			a.logger.Info().Msgf("%q (%s) installed", dist, v)
-		}
-
-		postInstallMessage := strings.TrimSpace(a.def.Sources[dist].PostInstallMessage)
-		if len(postInstallMessage) > 0 {
-			fmt.Printf("===> Post install message for %s <===\n%s\n", aurora.Bold(dist), postInstallMessage)
-		}
+		a.postInstallMessage(r.dist, "install")
 	}
 
 	if errored {
@@ -344,13 +470,16 @@ func (a *App) Install(specs ...string) error {
 	return nil
 }
 
-func (a *App) install(dist, version string) (string, error) {
+// install installs version of dist, logging with the logger in ctx
+func (a *App) install(ctx context.Context, dist, version string) (string, error) {
+	logger := zerolog.Ctx(ctx)
+
 	// Check if distribution is managed by us
 	if a.fetchers[dist] == nil {
-		return "", fmt.Errorf("no fetcher found or missing token for %q", dist)
+		return "", fmt.Errorf("no fetcher found for %q", dist)
 	}
 	if _, ok := a.fetchers[dist]; !ok {
-		a.logger.Error().Msgf("no such distribution %q", dist)
+		logger.Error().Msgf("no such distribution %q", dist)
 		return "", nil
 	}
 
@@ -358,8 +487,8 @@ func (a *App) install(dist, version string) (string, error) {
 
 	// If version is not specified, install most recent
 	if version == "" {
-		version = a.GetMostRecent(dist)
-		a.logger.Warn().Msgf("version for %q not specified; using %q", dist, version)
+		version = a.mostRecentInstallable(ctx, dist)
+		logger.Warn().Msgf("version for %q not specified; using %q", dist, version)
 	}
 
 	if version == "" {
@@ -373,7 +502,7 @@ func (a *App) install(dist, version string) (string, error) {
 	}
 	version = cleanVersion.String()
 	if stringInSlice(version, versions) {
-		a.logger.Warn().Msgf("version %q already installed for %q", version, dist)
+		logger.Warn().Msgf("version %q already installed for %q", version, dist)
 		return version, ErrAlreadyInstalled
 	}
 
@@ -384,14 +513,35 @@ func (a *App) install(dist, version string) (string, error) {
 		}
 	}
 
-	ctx := a.logger.WithContext(context.TODO())
+	// Select release asset if needed
+	installer := a.installers[dist]
+	f, i, err := a.resolve(dist, version, platform.Current())
+	if err != nil {
+		return version, err
+	}
+	if a.def.Sources[dist].Fetch.Automatic() {
+		installer = i.Factory(i.Binaries)
+	}
+
+	// Downloaded files are verified before being stored or used
+	verify := func(file string) error {
+		return a.verify(ctx, f, dist, version, platform.Current(), file, m)
+	}
+	fetcher, err := f.Factory(append(a.fetchOptions(), fetch.WithVerify(verify))...)
+	if err != nil {
+		return version, err
+	}
+
 	if a.dryrun {
-		a.logger.Warn().Msgf("dry-run mode: skipping install for %q (%s)", dist, version)
+		logger.Warn().Msgf("dry-run mode: skipping install for %q (%s)", dist, version)
 		return version, nil
 	}
 
 	// Call fetcher for distribution
-	file, err := a.fetchers[dist].Fetch(ctx, dist, version, m)
+	file, err := fetcher.Fetch(ctx, dist, version, m)
+	if errors.Is(err, fetch.ErrNotFound) {
+		a.setAvailable(dist, version, platform.Current(), false)
+	}
 	if err != nil {
 		return version, err
 	}
@@ -408,15 +558,15 @@ func (a *App) install(dist, version string) (string, error) {
 		}
 	}
 
-	if a.installers[dist] == nil {
+	if installer == nil {
 		return version, fmt.Errorf("no installer found for %s", dist)
 	}
 
 	if a.dryrun {
-		a.logger.Warn().Msgf("dry-run mode: skipping install for %q (%s)", dist, version)
+		logger.Warn().Msgf("dry-run mode: skipping install for %q (%s)", dist, version)
 		return version, nil
 	}
-	err = a.installers[dist].Install(
+	err = installer.Install(
 		file,
 		filepath.Join(
 			a.getBinDirFor(dist),
@@ -431,11 +581,10 @@ func (a *App) install(dist, version string) (string, error) {
 
 	// Install new shim version if needed
 	if dist == "binenv" {
-		a.logger.Info().Msgf("executing self install using bindir %s", a.bindir)
+		logger.Info().Msgf("executing self install using bindir %s", a.bindir)
 		err = a.selfInstall(version)
 		if err != nil {
-			a.logger.Error().Err(err).Msg("unable to set-up myself")
-			os.Exit(1)
+			return version, fmt.Errorf("unable to set-up myself: %w", err)
 		}
 	}
 
@@ -447,6 +596,29 @@ func (a *App) install(dist, version string) (string, error) {
 	return version, nil
 }
 
+// verify checks the integrity of the file downloaded for dist version on
+// platform p
+func (a *App) verify(ctx context.Context, f fetch.Fetch, dist, version string, p platform.Platform, file string, m mapping.Mapper) error {
+	logger := zerolog.Ctx(ctx)
+
+	if !f.Verifiable() {
+		logger.Debug().Msgf("no checksum or signature defined for %q", dist)
+		return nil
+	}
+
+	if a.insecureSkipVerify {
+		logger.Warn().Msgf("skipping verification for %q (%s)", dist, version)
+		return nil
+	}
+
+	err := f.Verify(ctx, file, version, m, p, a.fetchOptions()...)
+	if err != nil {
+		return fmt.Errorf("unable to verify %q (%s): %w", dist, version, err)
+	}
+
+	return nil
+}
+
 // Uninstall installs or update a distribution
 func (a *App) Uninstall(specs ...string) error {
 	// We accept either
@@ -652,137 +824,434 @@ func (a *App) Update(definitions, all bool, nocache bool, which ...string) error
 		}
 	}
 
-	a.logger.Debug().Msgf("updating %d distributions", len(which))
+	source := a.updateSource
 	if nocache {
+		source = SourceLocal
+	}
+
+	a.logger.Debug().Msgf("updating %d distributions from %s source", len(which), source)
+	switch source {
+	case SourceLocal:
 		err = a.updateLocally(which...)
-	} else {
-		err = a.updateGithub()
+	case SourceAuto:
+		err = a.updateAuto(which...)
+	default:
+		_, err = a.updateGithub()
+	}
+	// Keep versions fetched before the interruption
+	if errors.Is(err, errUpdateInterrupted) {
+		a.saveCache()
+		a.validators.Save()
+		a.logger.Error().Err(err).Msg("update not complete")
+		os.Exit(1)
 	}
 	if err != nil {
 		return err
 	}
 
 	err = a.saveCache()
+	if err != nil {
+		return err
+	}
 
-	return err
+	if source == SourceLocal || source == SourceAuto {
+		err = a.validators.Save()
+		if err != nil {
+			a.logger.Warn().Err(err).Msg("unable to save validators cache")
+		}
+	}
+
+	return nil
 }
 
-func (a *App) updateGithub() error {
-	a.logger.Info().Msgf("retrieving distribution cache from %s", cacheURL)
-	resp, err := http.Get(cacheURL)
+// updateGithub merges the published cache into the local one
+// It returns distributions found in the published cache.
+func (a *App) updateGithub() (map[string]bool, error) {
+	// Prefer the cache with release metadata, but it may not be published
+	// yet
+	body, err := a.fetchRemoteCache(cacheV2URL)
 	if err != nil {
-		return err
+		a.logger.Debug().Err(err).Msgf("unable to fetch %s; using %s", cacheV2URL, cacheURL)
+		body, err = a.fetchRemoteCache(cacheURL)
 	}
-
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = json.Unmarshal([]byte(body), &a.cache)
+	remote, err := decodeCache(body)
 	if err != nil {
 		a.logger.Error().Err(err).Msg(`unable to unmarshal Github cache; try to "binenv update" locally`)
-		return err
+		return nil, err
 	}
 
-	a.logger.Info().Msgf("fetched updates for %d distributions", len(a.cache))
+	found := a.mergeCache(remote)
+	a.logger.Info().Msgf("fetched updates for %d distributions", len(found))
 
-	return nil
+	return found, nil
 }
 
-func (a *App) fetcher(id int, jobs <-chan string, res chan<- jobResult, timeout time.Duration) {
-	a.logger.Debug().Msgf("fetcher %d starting", id)
-	for d := range jobs {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// mergeCache merges remote versions into the local cache and returns
+// distributions taken from remote
+// Remote entries replace local ones, except for private distributions.
+// Entries remote does not know are left as is.
+func (a *App) mergeCache(remote versionsCache) map[string]bool {
+	found := make(map[string]bool)
+	for dist, releases := range remote.Distributions {
+		if len(releases) == 0 {
+			continue
+		}
+		if a.private[dist] {
+			a.logger.Debug().Msgf("keeping local versions for private distribution %q", dist)
+			continue
+		}
 
-		var err error
+		found[dist] = true
 
+		// Availability is kept unless versions changed
+		if !sameVersions(a.cache[dist], releases) {
+			delete(a.available, dist)
+		}
+		a.cache[dist] = releases
+		if t, ok := remote.Refreshed[dist]; ok {
+			a.refreshed[dist] = t
+		} else {
+			delete(a.refreshed, dist)
+		}
+	}
+
+	return found
+}
+
+// sameVersions tells whether a and b hold the same versions, in any order
+func sameVersions(a, b []list.Release) bool {
+	va, vb := list.Versions(a), list.Versions(b)
+	slices.Sort(va)
+	slices.Sort(vb)
+
+	return slices.Equal(va, vb)
+}
+
+// updateAuto updates versions from the published cache, and lists releases
+// of distributions it lacks
+// All distributions are listed if the published cache can not be fetched.
+func (a *App) updateAuto(which ...string) error {
+	found, err := a.updateGithub()
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("unable to fetch distribution cache; listing releases instead")
+		return a.updateLocally(which...)
+	}
+
+	missing := []string{}
+	for _, d := range which {
+		if !found[d] {
+			missing = append(missing, d)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	a.logger.Info().Msgf("listing releases for %d distributions missing from cache", len(missing))
+	return a.updateLocally(missing...)
+}
+
+// fetchRemoteCache returns the content of the published cache at url
+func (a *App) fetchRemoteCache(url string) ([]byte, error) {
+	a.logger.Info().Msgf("retrieving distribution cache from %s", url)
+	resp, err := a.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// fetcher lists releases of distributions received in jobs
+// Distributions in known are listed incrementally.
+func (a *App) fetcher(ctx context.Context, id int, sched *scheduler, jobs <-chan string, res chan<- jobResult, known map[string]func(string) bool, timeout time.Duration) {
+	a.logger.Debug().Msgf("fetcher %d starting", id)
+	for d := range jobs {
 		r := jobResult{
 			distribution: d,
 		}
 
-		subctx := a.logger.WithContext(ctx)
-		r.versions, err = a.listers[d].Get(subctx)
-
-		if errors.Is(err, list.ErrGithubRateLimitClose) || errors.Is(err, list.ErrGithubRateLimited) {
-			a.logger.Error().Err(err).Msgf("rate limit prevents fetching versions for %q", d)
-			// return err
-			cancel()
+		// Hold back while the source is rate limited
+		r.err = sched.wait(ctx, a.listHost(d))
+		if r.err != nil {
+			res <- r
 			continue
 		}
-		if err != nil {
-			a.logger.Error().Err(err).Msgf("unable to fetch versions for %q", d)
-			// continue
-		}
 
-		if len(r.versions) == 0 {
-			a.logger.Warn().Msgf("found no versions for %q", d)
+		subctx, cancel := context.WithTimeout(a.logger.WithContext(ctx), timeout)
+		if inc, ok := a.listers[d].(list.IncrementalLister); ok && known[d] != nil {
+			r.releases, r.err = inc.GetNew(subctx, known[d])
+			r.partial = true
 		} else {
-			a.logger.Debug().Msgf("found versions %q for %q", strings.Join(r.versions, ","), d)
+			r.releases, r.err = a.listers[d].Get(subctx)
 		}
+		cancel()
 
 		res <- r
-
-		cancel()
 	}
 }
 
 func (a *App) updateLocally(which ...string) error {
-	bar := progressbar.Default(int64(len(which)), "updating distributions")
+	// Pick up where an interrupted update stopped
+	pending := a.resumeUpdate(which)
+
+	bar := progressbar.Default(int64(len(pending)), "updating distributions")
+
+	// Built beforehand since the cache is updated while fetching
+	known := a.knownVersions(pending)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Fetch as many GitHub releases as possible using batched GraphQL
+	// requests; the rest uses listers
+	// GraphQL lists all releases, so distributions that can be listed
+	// incrementally are left to listers.
+	full := slices.DeleteFunc(slices.Clone(pending), func(d string) bool {
+		return known[d] != nil
+	})
+	batched := a.updateGraphQL(ctx, full)
+	for d, releases := range batched {
+		a.setVersions(jobResult{distribution: d, releases: releases})
+	}
+	bar.Add(len(batched))
+
+	queue := []string{}
+	for _, d := range pending {
+		if _, ok := batched[d]; ok {
+			continue
+		}
+		if _, ok := a.listers[d]; !ok {
+			a.logger.Error().Msgf("no distribution named %q", d)
+			bar.Add(1)
+			continue
+		}
+		queue = append(queue, d)
+	}
 
+	sched := newScheduler(a.waitRateLimit)
 	jobs := make(chan string)
-	res := make(chan jobResult, 1000)
+	res := make(chan jobResult)
 	timeout := 1 * time.Second
 
 	for w := 1; w <= a.concurrency; w++ {
-		go a.fetcher(w, jobs, res, timeout)
+		go a.fetcher(ctx, w, sched, jobs, res, known, timeout)
 	}
 
-	count := 0
+	var (
+		inflight    int
+		deferred    []string
+		retries     = make(map[string]int)
+		interrupted = ctx.Done()
+	)
+
+	// Stop dispatching once interrupted, but collect running jobs
+	for inflight > 0 || (len(queue) > 0 && ctx.Err() == nil) {
+		var send chan<- string
+		if len(queue) > 0 && ctx.Err() == nil {
+			send = jobs
+		}
+
+		var next string
+		if send != nil {
+			next = queue[0]
+		}
+
+		select {
+		case send <- next:
+			a.logger.Debug().Msgf("feching available versions for %q", next)
+			queue = queue[1:]
+			inflight++
+		case <-interrupted:
+			a.logger.Warn().Msg("interrupted; waiting for running requests to complete")
+			interrupted = nil
+		case r := <-res:
+			inflight--
+
+			rle := &list.RateLimitError{}
+			switch {
+			case errors.As(r.err, &rle) && !rle.Exhausted():
+				// Releases were served; the quota is not exhausted yet
+				a.logger.Debug().Err(r.err).Msgf("found versions %q for %q", strings.Join(list.Versions(r.releases), ","), r.distribution)
+				a.setVersions(r)
+				bar.Add(1)
+			case errors.As(r.err, &rle):
+				until := sched.pause(a.listHost(r.distribution), rle)
+				retries[r.distribution]++
+				if retries[r.distribution] > maxRateLimitRetries {
+					a.logger.Error().Err(r.err).Msgf("giving up fetching versions for %q", r.distribution)
+					bar.Add(1)
+					continue
+				}
+				if a.waitRateLimit {
+					a.logger.Warn().Msgf("rate limit reached for %q; waiting until %s", r.distribution, until.Format(time.Kitchen))
+					queue = append(queue, r.distribution)
+					continue
+				}
+				deferred = append(deferred, r.distribution)
+			case errors.Is(r.err, errRateLimitPaused):
+				deferred = append(deferred, r.distribution)
+			case errors.Is(r.err, context.Canceled):
+				queue = append(queue, r.distribution)
+			default:
+				if r.err != nil {
+					a.logger.Error().Err(r.err).Msgf("unable to fetch versions for %q", r.distribution)
+				} else {
+					a.logger.Debug().Msgf("found versions %q for %q", strings.Join(list.Versions(r.releases), ","), r.distribution)
+				}
+				a.setVersions(r)
+				bar.Add(1)
+			}
+		}
+	}
+
+	close(jobs)
+
+	left := append(queue, deferred...)
+	if err := a.saveProgress(which, left); err != nil {
+		a.logger.Warn().Err(err).Msg("unable to save update progress")
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: %d distributions left; run the same update again to resume", errUpdateInterrupted, len(left))
+	}
+
+	hosts := make(map[string]int)
+	for _, d := range deferred {
+		hosts[a.listHost(d)]++
+	}
+	for host, n := range hosts {
+		until := sched.until(host)
+		a.logger.Warn().Msgf("rate limit reached for %s: %d distributions not updated; run the same update again after %s (in %s) to resume, or use --wait",
+			host, n, until.Format(time.Kitchen), time.Until(until).Round(time.Second))
+	}
+
+	return nil
+}
+
+// updateGraphQL fetches versions for github-releases distributions in which
+// using the GitHub GraphQL API, and returns them
+// Distributions that can not be fetched this way are not returned.
+func (a *App) updateGraphQL(ctx context.Context, which []string) map[string][]list.Release {
+	lists := make(map[string]list.List)
 	for _, d := range which {
-		bar.Add(1)
+		if src, ok := a.def.Sources[d]; ok {
+			lists[d] = src.List
+		}
+	}
 
-		a.logger.Debug().Msgf("feching available versions for %q", d)
-		if _, ok := a.listers[d]; !ok {
-			a.logger.Error().Msgf("no distribution named %q", d)
+	gql := list.NewGithubGraphQL(list.WithClient(a.client), list.WithCredentials(a.credentials))
+
+	supported := 0
+	for _, l := range lists {
+		if gql.Supports(l) {
+			supported++
+		}
+	}
+	// Not worth it
+	if supported < 2 {
+		return nil
+	}
+
+	versions, err := gql.Get(a.logger.WithContext(ctx), lists)
+	if errors.Is(err, list.ErrGithubGraphQLUnavailable) {
+		a.logger.Debug().Msg("no GitHub token available; not using GraphQL")
+		return nil
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("unable to fetch GitHub releases using GraphQL; falling back to REST")
+		return nil
+	}
+
+	a.logger.Debug().Msgf("fetched versions for %d of %d GitHub distributions using GraphQL", len(versions), supported)
+
+	return versions
+}
+
+// knownVersions returns functions telling if a version is already cached,
+// for distributions in which that can be listed incrementally
+// Distributions without cached versions, or not fully listed for
+// fullRefreshInterval, are not returned, so all their releases are listed.
+func (a *App) knownVersions(which []string) map[string]func(string) bool {
+	known := make(map[string]func(string) bool)
+	if a.fullUpdate {
+		return known
+	}
+
+	for _, d := range which {
+		if len(a.cache[d]) == 0 || time.Since(a.refreshed[d]) > fullRefreshInterval {
 			continue
 		}
 
-		jobs <- d
-		count++
+		cached := make(map[string]bool)
+		for _, r := range a.cache[d] {
+			cached[r.Version] = true
+		}
+		known[d] = func(version string) bool {
+			v, err := gov.NewVersion(version)
+			return err == nil && cached[v.String()]
+		}
 	}
 
-	close(jobs)
+	return known
+}
 
-	for c := 0; c < count; c++ {
-		r := <-res
+// setVersions updates the cache with versions found for a distribution
+// Partial results are merged with cached versions; others replace them.
+func (a *App) setVersions(r jobResult) {
+	// Skip this entry if no versions are provided
+	// see #157, #159, #162...
+	if len(r.releases) == 0 {
+		a.logger.Warn().Msgf("no versions found for %s; keeping previous versions %s", r.distribution, strings.Join(list.Versions(a.cache[r.distribution]), ","))
+		return
+	}
 
-		// Skip this entry if no versions are provided
-		// see #157, #159, #162...
-		if len(r.versions) == 0 {
-			a.logger.Warn().Msgf("no versions found for %s; keeping previous versions %s", r.distribution, strings.Join(a.cache[r.distribution], ","))
+	// Convert versions to canonical form
+	releases := []list.Release{}
+	seen := make(map[string]bool)
+	for _, rel := range r.releases {
+		version, err := gov.NewVersion(rel.Version)
+		if err != nil {
+			a.logger.Debug().Err(err).Msgf("ignoring invalid version for %q", r.distribution)
 			continue
 		}
+		rel.Version = version.String()
+		seen[rel.Version] = true
+		releases = append(releases, rel)
+	}
 
-		// Flush cache entry if
-		a.cache[r.distribution] = []string{}
+	if !r.partial {
+		a.cache[r.distribution] = releases
+		a.refreshed[r.distribution] = time.Now()
+		// Releases may have been published for more platforms since
+		delete(a.available, r.distribution)
+		return
+	}
 
-		// Convert versions to canonical form
-		for _, v := range r.versions {
-			version, err := gov.NewVersion(v)
-			if err != nil {
-				a.logger.Debug().Err(err).Msgf("ignoring invalid version for %q", r.distribution)
-				continue
-			}
-			a.cache[r.distribution] = append(a.cache[r.distribution], version.String())
+	// Fresh records replace cached ones
+	for _, rel := range a.cache[r.distribution] {
+		if !seen[rel.Version] {
+			releases = append(releases, rel)
 		}
 	}
-	return nil
+	a.cache[r.distribution] = releases
 }
 
 // Versions fetches available versions for the application
-func (a *App) Versions(freezemode bool, specs ...string) error {
+func (a *App) Versions(freezemode, wide bool, specs ...string) error {
 	if len(specs) == 0 {
 		for k := range a.cache {
 			specs = append(specs, k)
@@ -791,7 +1260,7 @@ func (a *App) Versions(freezemode bool, specs ...string) error {
 
 	sort.Strings(specs)
 
-	if !freezemode {
+	if !freezemode && !wide {
 		fmt.Printf("# Most recent first; legend: %s, %s, %s\n",
 			aurora.Reverse("active"),
 			aurora.Bold("installed"),
@@ -801,10 +1270,13 @@ func (a *App) Versions(freezemode bool, specs ...string) error {
 
 	var err error
 	for _, s := range specs {
-		if !freezemode {
-			err = a.versions(s)
-		} else {
+		switch {
+		case freezemode:
 			err = a.freeze(s)
+		case wide:
+			err = a.versionsWide(s)
+		default:
+			err = a.versions(s)
 		}
 		if err != nil {
 			a.logger.Error().Err(err).Msgf("unable to list versions for %q", s)
@@ -845,9 +1317,13 @@ func (a *App) versions(dist string) error {
 
 	fmt.Printf("%s: ", dist)
 
+	p := platform.Current()
 	for _, v := range available {
 		// var modifier aurora.Value
 		modifier := aurora.Faint(v)
+		if ok, known := a.availableOn(dist, v, p); known && !ok {
+			modifier = aurora.StrikeThrough(v)
+		}
 		if stringInSlice(v, installed) {
 			if v == guess {
 				modifier = aurora.Reverse(fmt.Sprintf("%s (%s)", v, why))
@@ -861,38 +1337,122 @@ func (a *App) versions(dist string) error {
 	return nil
 }
 
+// versionsWide lists versions for dist with their release metadata
+func (a *App) versionsWide(dist string) error {
+	curdir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("unable to determine current directory: %v", err)
+	}
+	available := a.GetAvailableVersionsFor(dist)
+	installed := a.GetInstalledVersionsFor(dist)
+	guess, why := a.GuessBestVersionFor(dist, curdir, "", installed)
+
+	releases := make(map[string]list.Release)
+	for _, r := range a.cache[dist] {
+		releases[r.Version] = r
+	}
+
+	fmt.Printf("%s:\n", dist)
+
+	p := platform.Current()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  VERSION\tSTATUS\tRELEASED\tFLAGS\tASSETS\tURL")
+	for _, v := range available {
+		status := "available"
+		if ok, known := a.availableOn(dist, v, p); known && !ok {
+			status = "unavailable on " + p.String()
+		}
+		if stringInSlice(v, installed) {
+			status = "installed"
+			if v == guess {
+				status = fmt.Sprintf("active (%s)", why)
+			}
+		}
+
+		r := releases[v]
+
+		flags := []string{}
+		if pv, err := gov.NewVersion(v); r.Prerelease || (err == nil && pv.Prerelease() != "") {
+			flags = append(flags, "prerelease")
+		}
+		if r.Draft {
+			flags = append(flags, "draft")
+		}
+
+		assets := "-"
+		if len(r.Assets) > 0 {
+			assets = strconv.Itoa(len(r.Assets))
+		}
+
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n",
+			v, status, releasedAgo(r.Published), orDash(strings.Join(flags, ",")), assets, orDash(r.URL))
+	}
+
+	return w.Flush()
+}
+
+// releasedAgo returns how long ago t was, in days, months or years
+func releasedAgo(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	days := int(time.Since(t).Hours() / 24)
+	switch {
+	case days < 1:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	case days < 60:
+		return fmt.Sprintf("%d days ago", days)
+	case days < 730:
+		return fmt.Sprintf("%d months ago", days/30)
+	default:
+		return fmt.Sprintf("%d years ago", days/365)
+	}
+}
+
+// orDash returns s, or "-" if s is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
 // Upgrade install last version of all locally installed distributions
-func (a *App) Upgrade(ignoreInstallErrors bool) error {
-	errored := false
+func (a *App) Upgrade(ctx context.Context, ignoreInstallErrors bool) error {
+	dists := []string{}
 	for dist := range a.cache {
-
 		// ignore uninstalled distribution
 		installed := a.GetInstalledVersionsFor(dist)
 		if len(installed) == 0 {
 			continue
 		}
+		dists = append(dists, dist)
+	}
+	sort.Strings(dists)
 
-		// get last known version
-		version := a.GetMostRecent(dist)
+	// Last known versions are selected by workers
+	jobs := []installJob{}
+	for _, dist := range dists {
+		jobs = append(jobs, installJob{dist: dist})
+	}
 
-		// call install function
-		v, err := a.install(dist, version)
+	results := a.installAll(ctx, jobs)
+	a.saveAvailability()
+	if len(results) > 1 {
+		printSummary(os.Stdout, results)
+	}
 
-		// process install errors logic.
-		if err != nil && !errors.Is(err, ErrAlreadyInstalled) {
-			a.logger.Error().Err(err).Msgf("unable to install %q (%s)", dist, v)
+	errored := false
+	for _, r := range results {
+		if r.status == statusFailed {
 			errored = true
 			continue
 		}
-
-		if err == nil {
-			a.logger.Info().Msgf("%q (%s) installed", dist, v)
-		}
-
-		postInstallMessage := strings.TrimSpace(a.def.Sources[dist].PostInstallMessage)
-		if len(postInstallMessage) > 0 {
-			fmt.Printf("===> Post upgrade message for %s <===\n%s\n", aurora.Bold(dist), postInstallMessage)
-		}
+		a.postInstallMessage(r.dist, "upgrade")
 	}
 
 	if errored && !ignoreInstallErrors {
@@ -902,6 +1462,14 @@ func (a *App) Upgrade(ignoreInstallErrors bool) error {
 	return nil
 }
 
+// postInstallMessage shows dist post install message, if any
+func (a *App) postInstallMessage(dist, action string) {
+	postInstallMessage := strings.TrimSpace(a.def.Sources[dist].PostInstallMessage)
+	if len(postInstallMessage) > 0 {
+		fmt.Printf("===> Post %s message for %s <===\n%s\n", action, aurora.Bold(dist), postInstallMessage)
+	}
+}
+
 // CreateShimFor creates a shim for the distribution
 func (a *App) CreateShimFor(dist string) error {
 	// Should not happen
@@ -1008,6 +1576,11 @@ func (a *App) selfInstall(version string) error {
 	return nil
 }
 
+// readDistributions reads distributions definitions from the configuration
+// directory
+// Distributions defined in any file other than distributions.yaml and
+// bundleConfigFile are private, even when they redefine upstream ones: their
+// versions are listed locally and not taken from the remote cache.
 func (a *App) readDistributions() error {
 	wildcard := filepath.Join(a.configdir, "/*.yaml")
 
@@ -1016,10 +1589,30 @@ func (a *App) readDistributions() error {
 		return fmt.Errorf("unable to find any distributions file in %s: %w", a.configdir, err)
 	}
 
+	// Skip binenv own files
+	files = slices.DeleteFunc(files, func(f string) bool {
+		return filepath.Base(f) == configFile || filepath.Base(f) == credentialsFile
+	})
+
+	// Bundled definitions are read first, so other files override them
+	ordered := []string{}
+	for _, f := range files {
+		if filepath.Base(f) == bundleConfigFile {
+			ordered = append([]string{f}, ordered...)
+			continue
+		}
+		ordered = append(ordered, f)
+	}
+	files = ordered
+
 	// Nothing found,just fetch distributions from GH repo
 	if len(files) == 0 {
 		conf := filepath.Join(a.configdir, "/distributions.yaml")
 		if _, err := os.Stat(conf); os.IsNotExist(err) {
+			if a.localDistributions {
+				a.def = &Distributions{Sources: make(map[string]Sources)}
+				return nil
+			}
 			err := a.fetchDistributions(conf)
 			if err != nil {
 				return fmt.Errorf("unable to fetch distributions: %w", err)
@@ -1032,8 +1625,10 @@ func (a *App) readDistributions() error {
 
 	a.def = &Distributions{}
 	a.def.Sources = make(map[string]Sources)
+	a.private = make(map[string]bool)
 
 	for _, f := range files {
+
 		yml, err := os.ReadFile(f)
 		if err != nil {
 			return fmt.Errorf("unable to read file '%s': %w", f, err)
@@ -1045,8 +1640,12 @@ func (a *App) readDistributions() error {
 			return err
 		}
 
+		// Definitions from other files override upstream ones; bundled
+		// definitions are copies of upstream ones
+		public := filepath.Base(f) == "distributions.yaml" || filepath.Base(f) == bundleConfigFile
 		for k, v := range dsts.Sources {
 			a.def.Sources[k] = v
+			a.private[k] = !public
 		}
 	}
 
@@ -1056,7 +1655,7 @@ func (a *App) readDistributions() error {
 func (a *App) fetchDistributions(conf string) error {
 	a.logger.Info().Msg("updating distribution list")
 	a.logger.Debug().Msgf("retrieving distribution list from %s", distributionsURL)
-	resp, err := http.Get(distributionsURL)
+	resp, err := a.client.Get(distributionsURL)
 	if err != nil {
 		return err
 	}
@@ -1259,10 +1858,18 @@ func (a *App) getDistributionsFromLock() ([]string, []string) {
 		return distributions, lines
 	}
 
+	return a.readLock(lockfile)
+}
+
+// readLock returns distributions and constraints lines found in lockfile
+func (a *App) readLock(lockfile string) ([]string, []string) {
+	var distributions []string
+	var lines []string
+
 	// lock file is found
 	f, err := os.Open(lockfile)
 	if err != nil {
-		a.logger.Error().Err(err).Msg("unanle to open .binenv.lock")
+		a.logger.Error().Err(err).Msgf("unable to open %s", lockfile)
 		return distributions, lines
 	}
 	defer f.Close()
@@ -1300,12 +1907,30 @@ func (a *App) loadCache() {
 		return
 	}
 
-	err = json.Unmarshal([]byte(js), &a.cache)
+	vc, err := decodeCache(js)
 
 	if err != nil {
 		a.logger.Error().Err(err).Msgf(`unable to unmarshal cache %s; try to "rm %s && binenv update"`, conf, conf)
 		return
 	}
+	a.cache = vc.Distributions
+	a.refreshed = vc.Refreshed
+	a.available = vc.Available
+}
+
+// loadValidators loads validators used to send conditional requests when
+// listing releases
+func (a *App) loadValidators() {
+	var mode os.FileMode = 0640
+	if a.global {
+		mode = 0644
+	}
+
+	var err error
+	a.validators, err = httpcache.Open(filepath.Join(a.cachedir, validatorsFile), mode)
+	if err != nil {
+		a.logger.Warn().Err(err).Msg("unable to read validators cache; all releases will be fetched")
+	}
 }
 
 func (a *App) saveCache() error {
@@ -1322,7 +1947,7 @@ func (a *App) saveCache() error {
 
 	cache = filepath.Join(cache, "/cache.json")
 
-	js, err := json.Marshal(&a.cache)
+	js, err := encodeCache(versionsCache{Distributions: a.cache, Refreshed: a.refreshed, Available: a.available})
 	if err != nil {
 		a.logger.Error().Err(err).Msgf("unable to marshal cache %q", cache)
 		return nil
@@ -1347,6 +1972,10 @@ func (a *App) saveCache() error {
 func (a *App) createInstallers() {
 	for k, v := range a.def.Sources {
 		i := v.Install.Factory(v.Install.Binaries)
+		if i == nil && v.Install.Type == "" && v.Fetch.Automatic() {
+			// Install type comes from the asset selected when installing
+			continue
+		}
 		if i == nil {
 			a.logger.Warn().Msgf("%q install method for %q is not implemented", v.Install.Type, k)
 			continue
@@ -1366,7 +1995,11 @@ func (a *App) createMappers() {
 
 func (a *App) createListers() {
 	for k, v := range a.def.Sources {
-		l := v.List.Factory()
+		l := v.List.Factory(
+			list.WithClient(a.client),
+			list.WithCredentials(a.credentials),
+			list.WithValidators(a.validators),
+		)
 		if l == nil {
 			a.logger.Warn().Msgf("%q list method for %q is not implemented", v.List.Type, k)
 			continue
@@ -1375,19 +2008,24 @@ func (a *App) createListers() {
 	}
 }
 
-func (a *App) createFetchers() error {
+func (a *App) createFetchers() {
 	for k, v := range a.def.Sources {
-		f, err := v.Fetch.Factory()
+		f, err := v.Fetch.Factory(a.fetchOptions()...)
 		if err != nil {
-			return fmt.Errorf("unable to create fetcher for %s: %w", k, err)
-		}
-		if f == nil {
-			a.logger.Warn().Msgf("%q fetch method for %q is not implemented", v.Fetch.Type, k)
+			a.logger.Warn().Err(err).Msgf("unable to create fetcher for %q", k)
 			continue
 		}
 		a.fetchers[k] = f
 	}
-	return nil
+}
+
+// fetchOptions returns options shared by all fetchers
+func (a *App) fetchOptions() []fetch.Option {
+	return []fetch.Option{
+		fetch.WithStore(a.artifacts),
+		fetch.WithClient(a.client),
+		fetch.WithCredentials(a.credentials),
+	}
 }
 
 // Functional options
@@ -1399,6 +2037,16 @@ func WithDiscard() func(*App) error {
 	}
 }
 
+// WithLocalDistributions prevents fetching distributions when none are
+// found in the configuration directory (e.g. when importing a bundle on an
+// offline machine)
+func WithLocalDistributions() func(*App) error {
+	return func(a *App) error {
+		a.localDistributions = true
+		return nil
+	}
+}
+
 func (a *App) setLogOutput(_ io.Writer) error {
 	a.logger = zerolog.Nop()
 
@@ -1491,11 +2139,53 @@ func (a *App) SetDryRun(v bool) {
 	}
 }
 
+// SetInsecureSkipVerify disables downloaded files verification
+func (a *App) SetInsecureSkipVerify(v bool) {
+	if v {
+		a.insecureSkipVerify = true
+	}
+}
+
+// SetHTTPConfig sets HTTP client configuration
+// Non-zero values take precedence over the configuration file.
+func (a *App) SetHTTPConfig(c httpclient.Config) {
+	a.httpConfig = c
+}
+
+// SetVersion sets binenv version (used in User-Agent)
+func (a *App) SetVersion(v string) {
+	a.version = v
+}
+
 // SetConcurrency sets the number of goroutines for cache update
 func (a *App) SetConcurrency(c int) {
 	a.concurrency = c
 }
 
+// SetWaitRateLimit sets whether updates wait for rate limits to reset
+// instead of leaving remaining distributions for a later update
+func (a *App) SetWaitRateLimit(w bool) {
+	a.waitRateLimit = w
+}
+
+// SetFullUpdate sets whether updates list all releases, instead of only
+// new ones
+func (a *App) SetFullUpdate(f bool) {
+	a.fullUpdate = f
+}
+
+// SetUpdateSource sets where updates get versions from (SourceRemote,
+// SourceLocal or SourceAuto)
+func (a *App) SetUpdateSource(s string) error {
+	switch s {
+	case SourceRemote, SourceLocal, SourceAuto:
+		a.updateSource = s
+		return nil
+	}
+
+	return fmt.Errorf("unknown update source %q (want %s, %s or %s)", s, SourceRemote, SourceLocal, SourceAuto)
+}
+
 // SetGlobal configures binenv to run in system-wide mode
 func (a *App) SetGlobal(g bool) {
 	if !g {
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devops-works/binenv/internal/list"
+)
+
+func TestApp_knownVersions(t *testing.T) {
+	a, _ := New()
+	a.cache = map[string][]list.Release{
+		"recent": {{Version: "1.0.0"}},
+		"stale":  {{Version: "1.0.0"}},
+		"empty":  {},
+	}
+	a.refreshed = map[string]time.Time{
+		"recent": time.Now().Add(-time.Hour),
+		"stale":  time.Now().Add(-fullRefreshInterval - time.Hour),
+		"empty":  time.Now(),
+	}
+
+	known := a.knownVersions([]string{"recent", "stale", "empty", "missing"})
+	if len(known) != 1 || known["recent"] == nil {
+		t.Fatalf("knownVersions() returned %d distributions, want only recent", len(known))
+	}
+	if !known["recent"]("v1.0") {
+		t.Errorf("known(v1.0) = false, want true")
+	}
+	if known["recent"]("1.1.0") {
+		t.Errorf("known(1.1.0) = true, want false")
+	}
+
+	a.SetFullUpdate(true)
+	if known := a.knownVersions([]string{"recent"}); len(known) != 0 {
+		t.Errorf("knownVersions() with full update returned %d distributions, want 0", len(known))
+	}
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/logrusorgru/aurora"
+
+	"github.com/devops-works/binenv/internal/progress"
+)
+
+// CacheList lists downloaded artifacts
+func (a *App) CacheList() error {
+	entries, err := a.artifacts.List()
+	if err != nil {
+		a.logger.Error().Err(err).Msgf("unable to list artifacts in %s", a.artifacts.Dir())
+		return err
+	}
+
+	for _, e := range entries {
+		fmt.Printf("%s %s %s\n",
+			aurora.Faint(e.Fetched.Format("2006-01-02 15:04")),
+			aurora.Bold(fmt.Sprintf("%9s", progress.Size(e.Size))),
+			e.URL,
+		)
+	}
+
+	return nil
+}
+
+// CacheSize shows disk space used by downloaded artifacts
+func (a *App) CacheSize() error {
+	entries, err := a.artifacts.List()
+	if err != nil {
+		a.logger.Error().Err(err).Msgf("unable to list artifacts in %s", a.artifacts.Dir())
+		return err
+	}
+
+	size, err := a.artifacts.Size()
+	if err != nil {
+		a.logger.Error().Err(err).Msgf("unable to compute size for %s", a.artifacts.Dir())
+		return err
+	}
+
+	fmt.Printf("%d artifacts using %s in %s\n", len(entries), progress.Size(size), a.artifacts.Dir())
+
+	return nil
+}
+
+// CacheClean removes all downloaded artifacts
+func (a *App) CacheClean() error {
+	size, err := a.artifacts.Size()
+	if err != nil {
+		a.logger.Error().Err(err).Msgf("unable to compute size for %s", a.artifacts.Dir())
+		return err
+	}
+
+	err = a.artifacts.Clean()
+	if err != nil {
+		a.logger.Error().Err(err).Msgf("unable to clean %s", a.artifacts.Dir())
+		return err
+	}
+
+	a.logger.Info().Msgf("removed %s of artifacts", progress.Size(size))
+
+	return nil
+}
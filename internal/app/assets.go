@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	gov "github.com/hashicorp/go-version"
+
+	"github.com/devops-works/binenv/internal/asset"
+	"github.com/devops-works/binenv/internal/fetch"
+	"github.com/devops-works/binenv/internal/install"
+	"github.com/devops-works/binenv/internal/list"
+	"github.com/devops-works/binenv/internal/platform"
+)
+
+// resolve returns the fetch and install configurations for dist version on
+// platform p
+// When the release asset is selected automatically, the fetch URL is set to
+// the asset download URL, and the install type defaults to the asset one.
+func (a *App) resolve(dist, version string, p platform.Platform) (fetch.Fetch, install.Install, error) {
+	f, i := a.def.Sources[dist].Fetch, a.def.Sources[dist].Install
+	if !f.Automatic() {
+		return f, i, nil
+	}
+
+	u, name, err := a.selectAsset(dist, version, p)
+	if err != nil {
+		if f.URL == "" {
+			return f, i, err
+		}
+		a.logger.Debug().Err(err).Msgf("falling back to URL template for %q (%s) on %s", dist, version, p)
+		return f, i, nil
+	}
+
+	a.logger.Debug().Msgf("selected asset %q for %q (%s) on %s", name, dist, version, p)
+
+	f.URL = u
+	if i.Type == "" {
+		i.Type = asset.InstallType(name)
+	}
+
+	return f, i, nil
+}
+
+// selectAsset returns the download URL and name of the dist version release
+// asset matching platform p
+func (a *App) selectAsset(dist, version string, p platform.Platform) (string, string, error) {
+	rel, ok := a.release(dist, version)
+	if !ok || len(rel.Assets) == 0 {
+		return "", "", fmt.Errorf("no release assets known for %q (%s); may be run 'binenv update %s' ?", dist, version, dist)
+	}
+
+	name, err := asset.Select(rel.Assets, rel.Version, p)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to select asset for %q (%s): %w", dist, version, err)
+	}
+
+	u, err := downloadURL(rel.URL, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	return u, name, nil
+}
+
+// release returns the cached release record for dist version
+func (a *App) release(dist, version string) (list.Release, bool) {
+	// Cached versions are usually in canonical form already
+	for _, r := range a.cache[dist] {
+		if r.Version == version {
+			return r, true
+		}
+	}
+
+	want, err := gov.NewVersion(version)
+	if err != nil {
+		return list.Release{}, false
+	}
+
+	for _, r := range a.cache[dist] {
+		v, err := gov.NewVersion(r.Version)
+		if err == nil && v.Equal(want) {
+			return r, true
+		}
+	}
+
+	return list.Release{}, false
+}
+
+// downloadURL returns the URL of asset name for a release page URL
+// GitHub and Gitea serve release pages under /releases/tag/<tag> and their
+// assets under /releases/download/<tag>/<name>.
+func downloadURL(release, name string) (string, error) {
+	base, tag, ok := strings.Cut(release, "/releases/tag/")
+	if !ok || tag == "" {
+		return "", fmt.Errorf("unable to guess asset download URL from release URL %q", release)
+	}
+
+	return base + "/releases/download/" + tag + "/" + url.PathEscape(name), nil
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/devops-works/binenv/internal/asset"
+	"github.com/devops-works/binenv/internal/mapping"
+	"github.com/devops-works/binenv/internal/platform"
+	"github.com/devops-works/binenv/internal/tpl"
+)
+
+// availableOn tells whether dist version has a release for platform p,
+// according to recorded probes and release assets
+// The second value is false when this is not known.
+func (a *App) availableOn(dist, version string, p platform.Platform) (bool, bool) {
+	a.availableMu.Lock()
+	ok, known := a.available[dist][p.String()][version]
+	a.availableMu.Unlock()
+	if known {
+		return ok, true
+	}
+
+	return a.hasAsset(dist, version, p)
+}
+
+// hasAsset tells whether dist version release assets include one for
+// platform p
+// The second value is false when this can not be told, e.g. when assets are
+// not known, or when the fetch URL does not point to release assets.
+func (a *App) hasAsset(dist, version string, p platform.Platform) (bool, bool) {
+	rel, ok := a.release(dist, version)
+	if !ok || len(rel.Assets) == 0 {
+		return false, false
+	}
+
+	f := a.def.Sources[dist].Fetch
+	if f.Automatic() {
+		_, err := asset.Select(rel.Assets, rel.Version, p)
+		switch {
+		case err == nil:
+			return true, true
+		case errors.Is(err, asset.ErrNoMatch) && f.URL == "":
+			return false, true
+		}
+		// Ambiguous assets, or URL template fallback
+		return false, false
+	}
+
+	if f.Type != "" && f.Type != "download" {
+		return false, false
+	}
+
+	rendered, err := tpl.NewFor(version, a.mapper(dist), p).Render(f.URL)
+	if err != nil {
+		return false, false
+	}
+
+	base, _, ok := strings.Cut(rel.URL, "/releases/tag/")
+	if !ok || !strings.HasPrefix(rendered, base+"/releases/download/") {
+		return false, false
+	}
+
+	u, err := url.Parse(rendered)
+	if err != nil {
+		return false, false
+	}
+
+	return slices.Contains(rel.Assets, path.Base(u.Path)), true
+}
+
+// probe tells whether dist version has a release for platform p
+// When this is not known, the release URL is probed and the result recorded.
+// Versions are deemed available when probing fails.
+func (a *App) probe(ctx context.Context, dist, version string, p platform.Platform) bool {
+	if ok, known := a.availableOn(dist, version, p); known {
+		return ok
+	}
+
+	f, _, err := a.resolve(dist, version, p)
+	if err != nil {
+		a.logger.Debug().Err(err).Msgf("unable to probe %q (%s) on %s", dist, version, p)
+		return true
+	}
+
+	ok, err := f.Available(ctx, version, a.mapper(dist), p, a.fetchOptions()...)
+	if err != nil {
+		a.logger.Debug().Err(err).Msgf("unable to probe %q (%s) on %s", dist, version, p)
+		return true
+	}
+
+	a.setAvailable(dist, version, p, ok)
+
+	return ok
+}
+
+// setAvailable records whether dist version has a release for platform p
+func (a *App) setAvailable(dist, version string, p platform.Platform, ok bool) {
+	a.availableMu.Lock()
+	defer a.availableMu.Unlock()
+
+	if a.available[dist] == nil {
+		a.available[dist] = make(availability)
+	}
+	if a.available[dist][p.String()] == nil {
+		a.available[dist][p.String()] = make(map[string]bool)
+	}
+	a.available[dist][p.String()][version] = ok
+	a.availableChanged = true
+}
+
+// withoutUnavailable returns versions of dist, except those known to have no
+// release for the running platform
+func (a *App) withoutUnavailable(dist string, versions []string) []string {
+	p := platform.Current()
+
+	return slices.DeleteFunc(slices.Clone(versions), func(v string) bool {
+		ok, known := a.availableOn(dist, v, p)
+		return known && !ok
+	})
+}
+
+// saveAvailability saves the cache when availability was recorded
+func (a *App) saveAvailability() {
+	if !a.availableChanged {
+		return
+	}
+
+	if err := a.saveCache(); err != nil {
+		a.logger.Warn().Err(err).Msg("unable to save versions availability")
+	}
+}
+
+// mapper returns the mapper for dist, if any
+func (a *App) mapper(dist string) mapping.Mapper {
+	if m, ok := a.mappers[dist]; ok {
+		return m
+	}
+
+	return nil
+}
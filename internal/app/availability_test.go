@@ -0,0 +1,112 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/devops-works/binenv/internal/fetch"
+	"github.com/devops-works/binenv/internal/install"
+	"github.com/devops-works/binenv/internal/list"
+	"github.com/devops-works/binenv/internal/platform"
+)
+
+func TestApp_skipUnavailable(t *testing.T) {
+	var probes atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			probes.Add(1)
+		}
+		// 3.0.0 has no release for any platform
+		if r.URL.Path == "/tool-3.0.0" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("#!/bin/sh\n"))
+	}))
+	defer ts.Close()
+
+	a := newBundleApp(t, ts.Client())
+	a.bindir = t.TempDir()
+	a.linkdir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(a.bindir, "shim"), nil, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(a.getBinDirFor("tool"), "1.0.0"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	a.def.Sources["tool"] = Sources{
+		Fetch:   fetch.Fetch{URL: ts.URL + "/tool-{{ .Version }}"},
+		Install: install.Install{Type: "direct"},
+	}
+	a.createFetchers()
+	a.createInstallers()
+	a.cache["tool"] = []list.Release{
+		{Version: "3.1.0-rc1"},
+		{Version: "3.0.0"},
+		{Version: "2.0.0"},
+		{Version: "1.0.0"},
+	}
+
+	// Probes use the caller context; versions are deemed available when
+	// probing fails
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := a.mostRecentInstallable(canceled, "tool"); got != "3.0.0" {
+		t.Errorf("mostRecentInstallable() with canceled context = %q, want 3.0.0", got)
+	}
+	if n := probes.Load(); n != 0 {
+		t.Errorf("mostRecentInstallable() sent %d probes with a canceled context", n)
+	}
+
+	ctx := context.Background()
+	if got := a.mostRecentInstallable(ctx, "tool"); got != "2.0.0" {
+		t.Errorf("mostRecentInstallable() = %q, want 2.0.0", got)
+	}
+	if ok, known := a.availableOn("tool", "3.0.0", platform.Current()); !known || ok {
+		t.Errorf("availableOn() = %t, %t, want false, true", ok, known)
+	}
+	// Recorded results are not probed again
+	probes.Store(0)
+	if got := a.mostRecentInstallable(ctx, "tool"); got != "2.0.0" {
+		t.Errorf("mostRecentInstallable() = %q, want 2.0.0", got)
+	}
+	if n := probes.Load(); n != 0 {
+		t.Errorf("mostRecentInstallable() sent %d probes for recorded versions", n)
+	}
+
+	// Lockfile resolution
+	dir := t.TempDir()
+	available := a.GetAvailableVersionsFor("tool")
+	installed := a.GetInstalledVersionsFor("tool")
+	tests := []struct {
+		lock string
+		want string
+	}{
+		{lock: "tool>=1.0", want: "2.0.0"},
+		{lock: "tool=3.0.0", want: ""},
+		{lock: "tool<2.0", want: "1.0.0"},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(filepath.Join(dir, ".binenv.lock"), []byte(tt.lock+"\n"), 0640); err != nil {
+			t.Fatal(err)
+		}
+		if got := a.guessInstallable(ctx, "tool", dir, available, installed); got != tt.want {
+			t.Errorf("guessInstallable() for %q = %q, want %q", tt.lock, got, tt.want)
+		}
+	}
+
+	// Upgrades skip versions without release
+	if err := a.Upgrade(ctx, true); err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	if got, want := a.GetInstalledVersionsFor("tool"), []string{"2.0.0", "1.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("installed versions after Upgrade() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,454 @@
+package app
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	gov "github.com/hashicorp/go-version"
+	"gopkg.in/yaml.v2"
+
+	"github.com/devops-works/binenv/internal/fetch"
+	"github.com/devops-works/binenv/internal/list"
+	"github.com/devops-works/binenv/internal/mapping"
+	"github.com/devops-works/binenv/internal/platform"
+	"github.com/devops-works/binenv/internal/store"
+)
+
+// Bundle layout
+//
+// bundle.json          manifest, always first
+// distributions.yaml   bundled distributions definitions
+// cache.json           bundled distributions versions
+// artifacts/<sha256>   artifacts, checksums and signatures
+const (
+	bundleFormat        = 1
+	bundleManifest      = "bundle.json"
+	bundleDistributions = "distributions.yaml"
+	bundleCache         = "cache.json"
+	bundleArtifacts     = "artifacts/"
+
+	// Imported definitions are written in this file; they are read before
+	// other distributions files, which override them
+	bundleConfigFile = "zz-bundle.yaml"
+)
+
+// manifest describes a bundle content
+type manifest struct {
+	Format        int               `json:"format"`
+	Created       time.Time         `json:"created"`
+	Platforms     []string          `json:"platforms"`
+	Distributions map[string]string `json:"distributions"`
+	Artifacts     []store.Entry     `json:"artifacts"`
+}
+
+// BundleExport packs everything needed to install versions required by
+// lockfile offline on platforms into output
+// Artifacts are verified before being bundled.
+func (a *App) BundleExport(ctx context.Context, lockfile, output string, platforms []string) error {
+	plats := []platform.Platform{}
+	for _, p := range platforms {
+		plat, err := platform.Parse(p)
+		if err != nil {
+			return err
+		}
+		plats = append(plats, plat)
+	}
+	if len(plats) == 0 {
+		plats = append(plats, platform.Current())
+	}
+
+	distributions, lines := a.readLock(lockfile)
+	if len(distributions) == 0 {
+		return fmt.Errorf("no distributions found in %s", lockfile)
+	}
+
+	m := manifest{
+		Format:        bundleFormat,
+		Created:       time.Now(),
+		Distributions: make(map[string]string),
+	}
+	for _, p := range plats {
+		m.Platforms = append(m.Platforms, p.String())
+	}
+
+	def := Distributions{Sources: make(map[string]Sources)}
+	cache := make(map[string][]list.Release)
+	seen := make(map[string]bool)
+
+	ctx = a.logger.WithContext(ctx)
+
+	for i, dist := range distributions {
+		src, ok := a.def.Sources[dist]
+		if !ok {
+			return fmt.Errorf("no such distribution %q", dist)
+		}
+
+		constraint := strings.TrimPrefix(lines[i], dist)
+		version := matchConstraint(constraint, a.GetAvailableVersionsFor(dist))
+		if version == "" {
+			return fmt.Errorf(`unable to satisfy constraint %q for %q; may be run "binenv update %s" ?`, constraint, dist, dist)
+		}
+
+		var mapper mapping.Mapper
+		if v, ok := a.mappers[dist]; ok {
+			mapper = v
+		}
+
+		for _, p := range plats {
+			if !supports(src.SupportedPlatforms, p) {
+				a.logger.Warn().Msgf("%q is not available for %s; skipping", dist, p)
+				continue
+			}
+
+			f, _, err := a.resolve(dist, version, p)
+			if err != nil {
+				return err
+			}
+
+			verify := func(file string) error {
+				return a.verify(ctx, f, dist, version, p, file, mapper)
+			}
+			urls, err := f.Mirror(ctx, dist, version, mapper, p, append(a.fetchOptions(), fetch.WithVerify(verify))...)
+			if err != nil {
+				return fmt.Errorf("unable to fetch %q (%s) for %s: %w", dist, version, p, err)
+			}
+
+			for _, u := range urls {
+				if seen[u] {
+					continue
+				}
+				seen[u] = true
+
+				e, ok := a.artifacts.Stat(u)
+				if !ok {
+					return fmt.Errorf("artifact for %s not found in %s", u, a.artifacts.Dir())
+				}
+				m.Artifacts = append(m.Artifacts, e)
+			}
+		}
+
+		m.Distributions[dist] = version
+		def.Sources[dist] = src
+		// Release records are kept so assets can be selected offline
+		rel, ok := a.release(dist, version)
+		if !ok {
+			rel = list.Release{Version: version}
+		}
+		cache[dist] = []list.Release{rel}
+
+		a.logger.Info().Msgf("bundled %q (%s)", dist, version)
+	}
+
+	err := a.writeBundle(output, m, def, cache)
+	if err != nil {
+		os.Remove(output)
+		return fmt.Errorf("unable to write bundle %s: %w", output, err)
+	}
+
+	a.logger.Info().Msgf("wrote %d distributions and %d artifacts to %s", len(m.Distributions), len(m.Artifacts), output)
+
+	return nil
+}
+
+func (a *App) writeBundle(output string, m manifest, def Distributions, cache map[string][]list.Release) error {
+	fd, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	tw := tar.NewWriter(fd)
+
+	js, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, bundleManifest, js, m.Created); err != nil {
+		return err
+	}
+
+	yml, err := yaml.Marshal(def)
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, bundleDistributions, yml, m.Created); err != nil {
+		return err
+	}
+
+	js, err = encodeCache(versionsCache{Distributions: cache})
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, bundleCache, js, m.Created); err != nil {
+		return err
+	}
+
+	// Artifacts sharing the same content are only written once
+	written := make(map[string]bool)
+	for _, e := range m.Artifacts {
+		if written[e.Digest] {
+			continue
+		}
+		written[e.Digest] = true
+
+		err := tw.WriteHeader(&tar.Header{
+			Name:    bundleArtifacts + digestHex(e.Digest),
+			Mode:    0644,
+			Size:    e.Size,
+			ModTime: e.Fetched,
+		})
+		if err != nil {
+			return err
+		}
+
+		blob, err := a.artifacts.Open(e)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, blob)
+		blob.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return fd.Close()
+}
+
+// BundleImport seeds the configuration directory, versions cache and
+// artifacts store from a bundle created by BundleExport
+func (a *App) BundleImport(bundle string) error {
+	fd, err := os.Open(bundle)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	tr := tar.NewReader(fd)
+
+	var m *manifest
+	imported := 0
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read bundle %s: %w", bundle, err)
+		}
+
+		if m == nil && hdr.Name != bundleManifest {
+			return fmt.Errorf("invalid bundle %s: %s must come first", bundle, bundleManifest)
+		}
+
+		switch {
+		case hdr.Name == bundleManifest:
+			m = &manifest{}
+			if err := json.NewDecoder(tr).Decode(m); err != nil {
+				return fmt.Errorf("invalid bundle manifest: %w", err)
+			}
+			if m.Format != bundleFormat {
+				return fmt.Errorf("unsupported bundle format %d", m.Format)
+			}
+		case hdr.Name == bundleDistributions:
+			if err := a.importDistributions(tr); err != nil {
+				return fmt.Errorf("unable to import distributions: %w", err)
+			}
+		case hdr.Name == bundleCache:
+			if err := a.importCache(tr); err != nil {
+				return fmt.Errorf("unable to import versions cache: %w", err)
+			}
+		case strings.HasPrefix(hdr.Name, bundleArtifacts):
+			n, err := a.importArtifacts(m, path.Base(hdr.Name), tr)
+			if err != nil {
+				return fmt.Errorf("unable to import artifacts: %w", err)
+			}
+			imported += n
+		default:
+			a.logger.Warn().Msgf("ignoring unknown bundle entry %s", hdr.Name)
+		}
+	}
+
+	if m == nil {
+		return fmt.Errorf("invalid bundle %s: no manifest found", bundle)
+	}
+
+	dists := []string{}
+	for d, v := range m.Distributions {
+		dists = append(dists, fmt.Sprintf("%s (%s)", d, v))
+	}
+	sort.Strings(dists)
+
+	a.logger.Info().Msgf("imported %d artifacts for %s", imported, strings.Join(dists, ", "))
+
+	return nil
+}
+
+// importDistributions merges bundled definitions with previously imported
+// ones
+func (a *App) importDistributions(r io.Reader) error {
+	yml, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	bundled := Distributions{}
+	if err := yaml.Unmarshal(yml, &bundled); err != nil {
+		return err
+	}
+
+	conf := filepath.Join(a.configdir, bundleConfigFile)
+
+	def := Distributions{Sources: make(map[string]Sources)}
+	if yml, err := os.ReadFile(conf); err == nil {
+		if err := yaml.Unmarshal(yml, &def); err != nil {
+			return fmt.Errorf("unable to read %s: %w", conf, err)
+		}
+		if def.Sources == nil {
+			def.Sources = make(map[string]Sources)
+		}
+	}
+
+	for k, v := range bundled.Sources {
+		def.Sources[k] = v
+		a.def.Sources[k] = v
+	}
+
+	yml, err = yaml.Marshal(def)
+	if err != nil {
+		return err
+	}
+
+	var mode os.FileMode = 0750
+	if a.global {
+		mode = 0755
+	}
+	err = os.MkdirAll(a.configdir, mode)
+	if err != nil {
+		return fmt.Errorf("unable to create configuration directory '%s': %w", a.configdir, err)
+	}
+
+	mode = 0640
+	if a.global {
+		mode = 0644
+	}
+
+	return os.WriteFile(conf, yml, mode)
+}
+
+// importCache merges bundled versions in the versions cache
+func (a *App) importCache(r io.Reader) error {
+	js, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	bundled, err := decodeCache(js)
+	if err != nil {
+		return err
+	}
+
+	for dist, releases := range bundled.Distributions {
+		for _, rel := range releases {
+			if !stringInSlice(rel.Version, list.Versions(a.cache[dist])) {
+				a.cache[dist] = append(a.cache[dist], rel)
+			}
+		}
+	}
+
+	return a.saveCache()
+}
+
+// importArtifacts adds content read from r to the store for all manifest
+// entries matching digest
+// Content already in the store (e.g. imported by a previous bundle) is not
+// written again.
+func (a *App) importArtifacts(m *manifest, digest string, r io.Reader) (int, error) {
+	n := 0
+	for _, e := range m.Artifacts {
+		if digestHex(e.Digest) != digest {
+			continue
+		}
+
+		if err := a.artifacts.Add(e); err != nil {
+			if err := a.artifacts.Import(e, r); err != nil {
+				return n, err
+			}
+		}
+		a.logger.Debug().Msgf("imported artifact %s", e.URL)
+		n++
+	}
+
+	return n, nil
+}
+
+// matchConstraint returns the first version in versions satisfying
+// constraint
+func matchConstraint(constraint string, versions []string) string {
+	c, err := gov.NewConstraint(constraint)
+	if err != nil {
+		return ""
+	}
+
+	for _, v := range versions {
+		v1, err := gov.NewVersion(v)
+		if err != nil {
+			continue
+		}
+		if c.Check(v1) {
+			return v1.String()
+		}
+	}
+
+	return ""
+}
+
+// supports returns true if p is in platforms, or if platforms is empty
+func supports(platforms []platform.Platform, p platform.Platform) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+
+	for _, s := range platforms {
+		if s == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeTarFile(tw *tar.Writer, name string, content []byte, mtime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: mtime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(content)
+	return err
+}
+
+func digestHex(digest string) string {
+	_, h, _ := strings.Cut(digest, ":")
+	return h
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/fetch"
+	"github.com/devops-works/binenv/internal/list"
+	"github.com/devops-works/binenv/internal/store"
+)
+
+// newBundleApp returns an App using temporary directories
+func newBundleApp(t *testing.T, client *http.Client) *App {
+	t.Helper()
+
+	a, _ := New()
+	a.logger = zerolog.Nop()
+	a.cachedir = t.TempDir()
+	a.configdir = t.TempDir()
+	a.artifacts = store.New(filepath.Join(a.cachedir, "artifacts"), 0750)
+	a.client = client
+	a.def = &Distributions{Sources: make(map[string]Sources)}
+
+	return a
+}
+
+func TestApp_Bundle(t *testing.T) {
+	platforms := []string{"linux/amd64", "darwin/arm64"}
+
+	sums := &strings.Builder{}
+	for _, p := range platforms {
+		name := "tool-1.0.0-" + strings.ReplaceAll(p, "/", "-")
+		sum := sha256.Sum256([]byte(name))
+		fmt.Fprintf(sums, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/SHA256SUMS":
+			w.Write([]byte(sums.String()))
+		case r.URL.Path == "/BAD256SUMS":
+			w.Write([]byte(strings.Repeat("0", 64) + "  tool-1.0.0-linux-amd64\n"))
+		case strings.HasPrefix(r.URL.Path, "/tool-"):
+			// Content is the asset name
+			w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	src := Sources{
+		List: list.List{Type: "static", Versions: []string{"1.0.0", "0.9.0"}},
+		Fetch: fetch.Fetch{
+			URL:      ts.URL + "/tool-{{ .Version }}-{{ .OS }}-{{ .Arch }}",
+			Checksum: fetch.Checksum{URL: ts.URL + "/SHA256SUMS"},
+		},
+	}
+
+	exporter := newBundleApp(t, ts.Client())
+	// alias shares artifacts with tool
+	exporter.def.Sources["tool"] = src
+	exporter.def.Sources["alias"] = src
+	exporter.cache["tool"] = []list.Release{{Version: "1.0.0"}, {Version: "0.9.0"}}
+	exporter.cache["alias"] = []list.Release{{Version: "1.0.0"}}
+
+	lockfile := filepath.Join(t.TempDir(), ".binenv.lock")
+	if err := os.WriteFile(lockfile, []byte("tool>=0.9\nalias=1.0.0\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	bundle := filepath.Join(t.TempDir(), "tools.tar")
+
+	if err := exporter.BundleExport(context.Background(), lockfile, bundle, platforms); err != nil {
+		t.Fatalf("BundleExport() error = %v", err)
+	}
+
+	importer := newBundleApp(t, nil)
+	for i := 0; i < 2; i++ {
+		// Importing again finds artifacts already in the store
+		if err := importer.BundleImport(bundle); err != nil {
+			t.Fatalf("BundleImport() error = %v", err)
+		}
+	}
+
+	for _, p := range platforms {
+		u := ts.URL + "/tool-1.0.0-" + strings.ReplaceAll(p, "/", "-")
+		file, ok := importer.artifacts.Lookup(u)
+		if !ok {
+			t.Errorf("BundleImport() did not import %s", u)
+			continue
+		}
+		if b, _ := os.ReadFile(file); string(b) != filepath.Base(u) {
+			t.Errorf("BundleImport() imported %q for %s", b, u)
+		}
+	}
+	if _, ok := importer.artifacts.Lookup(ts.URL + "/SHA256SUMS"); !ok {
+		t.Errorf("BundleImport() did not import checksums")
+	}
+	for _, d := range []string{"tool", "alias"} {
+		if got := list.Versions(importer.cache[d]); len(got) != 1 || got[0] != "1.0.0" {
+			t.Errorf("BundleImport() versions for %q = %v, want [1.0.0]", d, got)
+		}
+	}
+
+	// Upstream definitions override bundled ones, which are not private
+	upstream := "sources:\n  tool:\n    fetch:\n      url: https://example.org/tool\n"
+	if err := os.WriteFile(filepath.Join(importer.configdir, "distributions.yaml"), []byte(upstream), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := importer.readDistributions(); err != nil {
+		t.Fatalf("readDistributions() error = %v", err)
+	}
+	if got := importer.def.Sources["tool"].Fetch.URL; got != "https://example.org/tool" {
+		t.Errorf("tool fetch URL = %q, want upstream one", got)
+	}
+	if got := importer.def.Sources["alias"].Fetch.URL; got != src.Fetch.URL {
+		t.Errorf("alias fetch URL = %q, want bundled one", got)
+	}
+	if importer.private["tool"] || importer.private["alias"] {
+		t.Errorf("bundled distributions are private: %v", importer.private)
+	}
+
+	// Artifacts failing verification are not exported
+	bad := src
+	bad.Fetch.Checksum.URL = ts.URL + "/BAD256SUMS"
+	exporter.def.Sources["tool"] = bad
+	exporter.artifacts = store.New(filepath.Join(t.TempDir(), "artifacts"), 0750)
+	if err := exporter.BundleExport(context.Background(), lockfile, bundle, platforms[:1]); err == nil {
+		t.Errorf("BundleExport() accepted an artifact not matching its checksum")
+	}
+	if _, ok := exporter.artifacts.Lookup(ts.URL + "/tool-1.0.0-linux-amd64"); ok {
+		t.Errorf("BundleExport() stored an artifact not matching its checksum")
+	}
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/devops-works/binenv/internal/list"
+)
+
+// cacheSchema is the current versions cache schema version
+//
+// Schema 1 (no version field) is a map of distributions to version strings.
+// Schema 2 stores release records with their metadata.
+const cacheSchema = 2
+
+// fullRefreshInterval is how often all releases of a distribution are listed
+// again, instead of only new ones, to pick up deleted releases
+const fullRefreshInterval = 7 * 24 * time.Hour
+
+// versionsCache is the versions cache file content
+type versionsCache struct {
+	Schema        int                       `json:"schema"`
+	Distributions map[string][]list.Release `json:"distributions"`
+	// Refreshed records when all releases of distributions were last listed
+	Refreshed map[string]time.Time `json:"refreshed,omitempty"`
+	// Available records whether versions of distributions have a release
+	// for platforms, when it can not be told from release assets
+	Available map[string]availability `json:"available,omitempty"`
+}
+
+// availability maps platforms (e.g. linux/arm64) to versions having (or not)
+// a release for them
+type availability map[string]map[string]bool
+
+// decodeCache reads a versions cache in any supported schema
+func decodeCache(js []byte) (versionsCache, error) {
+	// Schema 1 caches can not have non array values, so they are tried
+	// first: this way a distribution named after a schema 2 field can not be
+	// mistaken for one
+	v1 := make(map[string][]string)
+	if err := json.Unmarshal(js, &v1); err == nil {
+		cache := make(map[string][]list.Release, len(v1))
+		for dist, versions := range v1 {
+			cache[dist] = make([]list.Release, 0, len(versions))
+			for _, v := range versions {
+				cache[dist] = append(cache[dist], list.Release{Version: v})
+			}
+		}
+		return versionsCache{
+			Schema:        1,
+			Distributions: cache,
+			Refreshed:     make(map[string]time.Time),
+			Available:     make(map[string]availability),
+		}, nil
+	}
+
+	vc := versionsCache{}
+	if err := json.Unmarshal(js, &vc); err != nil {
+		return vc, err
+	}
+	if vc.Schema > cacheSchema {
+		return vc, fmt.Errorf("cache schema %d is not supported (binenv supports up to %d); please upgrade binenv", vc.Schema, cacheSchema)
+	}
+	if vc.Distributions == nil {
+		vc.Distributions = make(map[string][]list.Release)
+	}
+	if vc.Refreshed == nil {
+		vc.Refreshed = make(map[string]time.Time)
+	}
+	if vc.Available == nil {
+		vc.Available = make(map[string]availability)
+	}
+
+	return vc, nil
+}
+
+// encodeCache returns the current schema representation of vc
+func encodeCache(vc versionsCache) ([]byte, error) {
+	vc.Schema = cacheSchema
+	return json.Marshal(vc)
+}
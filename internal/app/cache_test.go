@@ -0,0 +1,88 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/list"
+)
+
+func TestApp_mergeCache(t *testing.T) {
+	listed := time.Now()
+	published := listed.Add(-72 * time.Hour)
+
+	a, _ := New()
+	a.logger = zerolog.Nop()
+	a.cache = map[string][]list.Release{
+		"public":  {{Version: "1.0.0"}},
+		"private": {{Version: "3.0.0"}},
+		"local":   {{Version: "0.1.0"}},
+		"v1":      {{Version: "1.0.0"}},
+		"same":    {{Version: "1.0.0"}, {Version: "0.9.0"}},
+	}
+	a.refreshed = map[string]time.Time{
+		"public": listed,
+		"v1":     listed,
+	}
+	a.available = map[string]availability{
+		"public": {"linux/amd64": {"1.0.0": true}},
+		"same":   {"linux/amd64": {"1.0.0": false}},
+	}
+	a.private = map[string]bool{"private": true}
+
+	remote := versionsCache{
+		Distributions: map[string][]list.Release{
+			"public":  {{Version: "1.1.0"}, {Version: "1.0.0"}},
+			"private": {{Version: "1.0.0"}},
+			"v1":      {{Version: "1.2.0"}},
+			"other":   {{Version: "2.0.0"}},
+			"same":    {{Version: "0.9.0"}, {Version: "1.0.0"}},
+			"empty":   {},
+		},
+		Refreshed: map[string]time.Time{
+			"public": published,
+		},
+	}
+
+	found := a.mergeCache(remote)
+
+	wantFound := map[string]bool{"public": true, "v1": true, "other": true, "same": true}
+	if !reflect.DeepEqual(found, wantFound) {
+		t.Errorf("mergeCache() = %v, want %v", found, wantFound)
+	}
+
+	wantCache := map[string][]string{
+		// Remote wins even if listed locally more recently
+		"public":  {"1.1.0", "1.0.0"},
+		"private": {"3.0.0"},
+		"local":   {"0.1.0"},
+		// Remote caches without refresh dates win too
+		"v1":    {"1.2.0"},
+		"other": {"2.0.0"},
+		"same":  {"0.9.0", "1.0.0"},
+	}
+	got := map[string][]string{}
+	for d, releases := range a.cache {
+		got[d] = list.Versions(releases)
+	}
+	if !reflect.DeepEqual(got, wantCache) {
+		t.Errorf("cache = %v, want %v", got, wantCache)
+	}
+
+	if !a.refreshed["public"].Equal(published) {
+		t.Errorf("refreshed[public] = %v, want %v", a.refreshed["public"], published)
+	}
+	if _, ok := a.refreshed["v1"]; ok {
+		t.Errorf("refreshed[v1] kept, want removed")
+	}
+	// Availability is only dropped when versions change
+	if _, ok := a.available["public"]; ok {
+		t.Errorf("available[public] kept, want removed")
+	}
+	if _, ok := a.available["same"]; !ok {
+		t.Errorf("available[same] removed, want kept")
+	}
+}
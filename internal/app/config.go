@@ -0,0 +1,110 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/devops-works/binenv/internal/auth"
+	"github.com/devops-works/binenv/internal/httpclient"
+	"github.com/devops-works/binenv/internal/rewrite"
+)
+
+// configFile is the binenv configuration file name in the configuration
+// directory; it is not a distributions file
+const configFile = "config.yaml"
+
+// credentialsFile holds per-host credentials in the configuration directory;
+// it is not a distributions file either
+const credentialsFile = "credentials.yaml"
+
+// Config holds binenv configuration
+type Config struct {
+	// Rewrite rules apply to every URL binenv fetches (e.g. to route
+	// traffic through an artifact proxy)
+	Rewrite rewrite.Rules `yaml:"rewrite"`
+	// HTTP configures the HTTP client; command line flags and environment
+	// variables take precedence
+	HTTP httpclient.Config `yaml:"http"`
+}
+
+// readConfig reads the optional configuration file
+func (a *App) readConfig() error {
+	conf := filepath.Join(a.configdir, configFile)
+
+	yml, err := os.ReadFile(conf)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to read configuration file %s: %w", conf, err)
+	}
+
+	c := Config{}
+	err = yaml.UnmarshalStrict(yml, &c)
+	if err != nil {
+		return fmt.Errorf("unable to parse configuration file %s: %w", conf, err)
+	}
+
+	err = c.Rewrite.Compile()
+	if err != nil {
+		return fmt.Errorf("invalid configuration file %s: %w", conf, err)
+	}
+
+	a.config = c
+
+	return nil
+}
+
+// readCredentials reads the optional credentials file
+func (a *App) readCredentials() error {
+	conf := filepath.Join(a.configdir, credentialsFile)
+
+	if st, err := os.Stat(conf); err == nil && st.Mode().Perm()&0077 != 0 {
+		a.logger.Warn().Msgf("credentials file %s is readable by others; you should chmod 600 it", conf)
+	}
+
+	r, err := auth.NewResolver(conf)
+	if err != nil {
+		return err
+	}
+	a.credentials = r
+
+	return nil
+}
+
+// createClient creates the HTTP client used for all requests
+func (a *App) createClient() error {
+	version := a.version
+	if version == "" {
+		version = "dev"
+	}
+
+	c, err := httpclient.New(
+		a.config.HTTP.Merge(a.httpConfig),
+		fmt.Sprintf("binenv/%s (+https://github.com/devops-works/binenv)", version),
+		a.logger,
+		func(rt http.RoundTripper) http.RoundTripper {
+			return rewrite.Transport{
+				Rules:  a.config.Rewrite,
+				Base:   rt,
+				Logger: &a.logger,
+				// Use credentials file entries for rewritten hosts
+				Authorize: func(r *http.Request) error {
+					_, err := a.credentials.Authorize(r, auth.Auth{})
+					return err
+				},
+			}
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	a.client = c
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package app
 
 import (
+	"fmt"
+
+	gov "github.com/hashicorp/go-version"
+
 	"github.com/devops-works/binenv/internal/fetch"
 	"github.com/devops-works/binenv/internal/install"
 	"github.com/devops-works/binenv/internal/list"
@@ -25,3 +29,36 @@ type Sources struct {
 	PostInstallMessage string              `yaml:"post_install_message"`
 	SupportedPlatforms []platform.Platform `yaml:"supported_platforms"`
 }
+
+// TestVersion shows how tag is turned into a version for dist
+// It returns an error when the tag would not be listed.
+func (a *App) TestVersion(dist, tag string) error {
+	src, ok := a.def.Sources[dist]
+	if !ok {
+		return fmt.Errorf("no such distribution %q", dist)
+	}
+	l := src.List
+
+	fmt.Printf("tag:            %s\n", tag)
+	fmt.Printf("exclude:        %s\n", orDash(l.Exclude))
+	fmt.Printf("prefix:         %s\n", orDash(l.Prefix))
+	fmt.Printf("version_regex:  %s\n", orDash(l.VersionRegex))
+
+	version, err := l.Version(tag)
+	if err != nil {
+		return err
+	}
+
+	v, err := gov.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("%q is not a valid version: %w", version, err)
+	}
+
+	kind := "stable"
+	if v.Prerelease() != "" {
+		kind = "prerelease"
+	}
+	fmt.Printf("version:        %s (%s)\n", v.String(), kind)
+
+	return nil
+}
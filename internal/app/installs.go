@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/progress"
+)
+
+// Install results status
+const (
+	statusInstalled = "installed"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+)
+
+// installJob is a distribution version to install
+type installJob struct {
+	dist    string
+	version string
+}
+
+// installResult holds the outcome of an installJob
+type installResult struct {
+	dist    string
+	version string
+	status  string
+	detail  string
+}
+
+// installAll installs jobs using up to a.concurrency workers
+// Results are returned in jobs order. When more than one job is given,
+// transfers progress is displayed on one line per distribution.
+func (a *App) installAll(ctx context.Context, jobs []installJob) []installResult {
+	results := make([]installResult, len(jobs))
+	if len(jobs) == 0 {
+		return results
+	}
+
+	ctx = a.logger.WithContext(ctx)
+
+	if len(jobs) > 1 {
+		m := progress.NewMulti(os.Stderr, progress.IsTerminal(os.Stderr))
+		defer m.Stop()
+
+		// Route logs through the progress display so they do not garble it
+		logger := a.logger.Output(zerolog.ConsoleWriter{
+			Out:        m,
+			TimeFormat: time.RFC3339,
+		})
+		ctx = progress.WithReporter(logger.WithContext(ctx), m)
+	}
+
+	workers := a.concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = a.installOne(ctx, jobs[i])
+			}
+		}()
+	}
+
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+
+	wg.Wait()
+
+	return results
+}
+
+// installOne installs job and logs the outcome using the logger in ctx
+func (a *App) installOne(ctx context.Context, job installJob) installResult {
+	logger := zerolog.Ctx(ctx)
+
+	r := installResult{
+		dist:    job.dist,
+		version: job.version,
+	}
+
+	v, err := a.install(ctx, job.dist, job.version)
+	if v != "" {
+		r.version = v
+	}
+
+	switch {
+	case errors.Is(err, ErrAlreadyInstalled):
+		r.status = statusSkipped
+		r.detail = "already installed"
+	case err != nil:
+		logger.Error().Err(err).Msgf("unable to install %q (%s)", job.dist, v)
+		r.status = statusFailed
+		r.detail = err.Error()
+	default:
+		logger.Info().Msgf("%q (%s) installed", job.dist, v)
+		r.status = statusInstalled
+	}
+
+	return r
+}
+
+// printSummary writes a table with results to out
+func printSummary(out io.Writer, results []installResult) {
+	counts := map[string]int{}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DISTRIBUTION\tVERSION\tSTATUS\tDETAILS")
+	for _, r := range results {
+		counts[r.status]++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.dist, r.version, r.status, r.detail)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "%d installed, %d skipped, %d failed\n",
+		counts[statusInstalled],
+		counts[statusSkipped],
+		counts[statusFailed],
+	)
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/devops-works/binenv/internal/fetch"
+	"github.com/devops-works/binenv/internal/install"
+)
+
+func TestApp_installAll(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tool-2.0.0" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("#!/bin/sh\n"))
+	}))
+	defer ts.Close()
+
+	a := newBundleApp(t, ts.Client())
+	a.bindir = t.TempDir()
+	a.linkdir = t.TempDir()
+	a.concurrency = 2
+	if err := os.WriteFile(filepath.Join(a.bindir, "shim"), nil, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(a.getBinDirFor("tool"), "1.0.0"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	direct := install.Install{Type: "direct"}
+	a.def.Sources["tool"] = Sources{
+		Fetch:   fetch.Fetch{URL: ts.URL + "/tool-{{ .Version }}"},
+		Install: direct,
+	}
+	a.def.Sources["broken"] = Sources{
+		Fetch:   fetch.Fetch{URL: ts.URL + "/broken-{{ .Version }}"},
+		Install: direct,
+	}
+	a.createFetchers()
+	a.createInstallers()
+
+	jobs := []installJob{
+		{dist: "tool", version: "2.0.0"},
+		{dist: "tool", version: "1.0.0"},
+		{dist: "broken", version: "1.0.0"},
+		{dist: "missing", version: "1.0.0"},
+	}
+
+	results := a.installAll(context.Background(), jobs)
+
+	// Results are in jobs order, failures do not stop other jobs
+	got := []string{}
+	for _, r := range results {
+		got = append(got, r.dist+" "+r.version+" "+r.status)
+	}
+	want := []string{
+		"tool 2.0.0 installed",
+		"tool 1.0.0 skipped",
+		"broken 1.0.0 failed",
+		"missing 1.0.0 failed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("installAll() = %v, want %v", got, want)
+	}
+	if results[2].detail == "" || results[3].detail == "" {
+		t.Errorf("installAll() failures without details: %+v", results)
+	}
+
+	if _, err := os.Stat(filepath.Join(a.getBinDirFor("tool"), "2.0.0")); err != nil {
+		t.Errorf("installAll() did not install tool 2.0.0: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(a.linkdir, "tool")); err != nil {
+		t.Errorf("installAll() did not create shim for tool: %v", err)
+	}
+
+	if got := a.installAll(context.Background(), nil); len(got) != 0 {
+		t.Errorf("installAll() without jobs = %v", got)
+	}
+}
+
+func TestPrintSummary(t *testing.T) {
+	buf := &bytes.Buffer{}
+	printSummary(buf, []installResult{
+		{dist: "tool", version: "2.0.0", status: statusInstalled},
+		{dist: "other", version: "1.0.0", status: statusSkipped, detail: "already installed"},
+		{dist: "broken", version: "1.0.0", status: statusFailed, detail: "not found"},
+	})
+
+	want := []string{
+		"DISTRIBUTION  VERSION  STATUS     DETAILS",
+		"tool          2.0.0    installed  ",
+		"other         1.0.0    skipped    already installed",
+		"broken        1.0.0    failed     not found",
+		"1 installed, 1 skipped, 1 failed",
+	}
+	if got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("printSummary() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
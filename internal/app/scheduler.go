@@ -0,0 +1,201 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/devops-works/binenv/internal/list"
+)
+
+// progressFile holds distributions left to update by an interrupted or rate
+// limited update, in the cache directory
+const progressFile = "update.json"
+
+// defaultRateLimitPause is used when a source does not tell when its quota
+// is restored
+const defaultRateLimitPause = time.Minute
+
+// maxRateLimitRetries is the number of times a distribution is retried after
+// hitting a rate limit
+const maxRateLimitRetries = 3
+
+// errRateLimitPaused is returned by scheduler.wait when the source is rate
+// limited and the scheduler does not wait
+var errRateLimitPaused = errors.New("source rate limit reached")
+
+// errUpdateInterrupted is returned when an update is interrupted by the user
+var errUpdateInterrupted = errors.New("update interrupted")
+
+// scheduler tracks rate limits reported by sources, so requests to a source
+// are held back until its quota is restored
+// Rate limits are tracked per host since they are enforced per API.
+type scheduler struct {
+	block bool
+
+	mu     sync.Mutex
+	resume map[string]time.Time
+}
+
+func newScheduler(block bool) *scheduler {
+	return &scheduler{
+		block:  block,
+		resume: make(map[string]time.Time),
+	}
+}
+
+// pause holds requests to host back until the quota in rle is restored, and
+// returns this date
+func (s *scheduler) pause(host string, rle *list.RateLimitError) time.Time {
+	until := rle.Reset
+	if until.Before(time.Now()) {
+		until = time.Now().Add(defaultRateLimitPause)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if until.After(s.resume[host]) {
+		s.resume[host] = until
+	}
+
+	return s.resume[host]
+}
+
+// until returns when requests to host can be sent again
+func (s *scheduler) until(host string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.resume[host]
+}
+
+// wait blocks until requests to host can be sent
+// When the scheduler does not block, errRateLimitPaused is returned instead
+// of waiting.
+func (s *scheduler) wait(ctx context.Context, host string) error {
+	for {
+		d := time.Until(s.until(host))
+		if d <= 0 {
+			return nil
+		}
+		if !s.block {
+			return errRateLimitPaused
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// listHost returns the host queried by the lister for distribution d
+func (a *App) listHost(d string) string {
+	src, ok := a.def.Sources[d]
+	if !ok {
+		return ""
+	}
+
+	u, err := url.Parse(src.List.URL)
+	if err != nil {
+		return ""
+	}
+
+	// Repository URLs share the API rate limit
+	if src.List.Type == "github-releases" && u.Host == "github.com" {
+		return "api.github.com"
+	}
+
+	return u.Host
+}
+
+// updateProgress records an unfinished update
+type updateProgress struct {
+	Requested []string  `json:"requested"`
+	Pending   []string  `json:"pending"`
+	Saved     time.Time `json:"saved"`
+}
+
+// resumeUpdate returns distributions left by a previous unfinished update of
+// the same distributions, or which if there is none
+func (a *App) resumeUpdate(which []string) []string {
+	js, err := os.ReadFile(filepath.Join(a.cachedir, progressFile))
+	if err != nil {
+		return which
+	}
+
+	p := updateProgress{}
+	if err := json.Unmarshal(js, &p); err != nil {
+		a.logger.Warn().Err(err).Msgf("ignoring invalid update progress file")
+		return which
+	}
+
+	requested := slices.Clone(which)
+	slices.Sort(requested)
+	if !slices.Equal(requested, p.Requested) {
+		return which
+	}
+
+	pending := []string{}
+	for _, d := range p.Pending {
+		if _, ok := a.listers[d]; ok {
+			pending = append(pending, d)
+		}
+	}
+
+	a.logger.Info().Msgf("resuming update from %s: %d of %d distributions left",
+		p.Saved.Format(time.Kitchen), len(pending), len(which))
+
+	return pending
+}
+
+// saveProgress records distributions left to update, or removes the progress
+// file when there is none
+func (a *App) saveProgress(which, pending []string) error {
+	file := filepath.Join(a.cachedir, progressFile)
+
+	if len(pending) == 0 {
+		err := os.Remove(file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	requested := slices.Clone(which)
+	slices.Sort(requested)
+
+	js, err := json.Marshal(updateProgress{
+		Requested: requested,
+		Pending:   pending,
+		Saved:     time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	var mode os.FileMode = 0750
+	if a.global {
+		mode = 0755
+	}
+	if err := os.MkdirAll(a.cachedir, mode); err != nil {
+		return fmt.Errorf("unable to create cache directory '%s': %w", a.cachedir, err)
+	}
+
+	mode = 0640
+	if a.global {
+		mode = 0644
+	}
+	return os.WriteFile(file, js, mode)
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/list"
+)
+
+// fakeLister returns releases and err
+type fakeLister struct {
+	releases []list.Release
+	err      error
+}
+
+func (f fakeLister) Get(ctx context.Context) ([]list.Release, error) {
+	return f.releases, f.err
+}
+
+func TestScheduler(t *testing.T) {
+	s := newScheduler(false)
+	ctx := context.Background()
+
+	later := time.Now().Add(time.Hour).Truncate(time.Second)
+	if got := s.pause("api.github.com", &list.RateLimitError{Reset: later}); !got.Equal(later) {
+		t.Errorf("scheduler.pause() = %s, want %s", got, later)
+	}
+	// Earlier resets do not shorten the pause
+	if got := s.pause("api.github.com", &list.RateLimitError{Reset: later.Add(-time.Minute)}); !got.Equal(later) {
+		t.Errorf("scheduler.pause() = %s, want %s", got, later)
+	}
+	// Past resets pause for defaultRateLimitPause
+	if got := s.pause("gitlab.com", &list.RateLimitError{Reset: time.Now().Add(-time.Hour)}); time.Until(got) <= 0 || time.Until(got) > defaultRateLimitPause {
+		t.Errorf("scheduler.pause() = %s, want in %s", got, defaultRateLimitPause)
+	}
+
+	if err := s.wait(ctx, "api.github.com"); !errors.Is(err, errRateLimitPaused) {
+		t.Errorf("scheduler.wait() error = %v, want %v", err, errRateLimitPaused)
+	}
+	if err := s.wait(ctx, "example.org"); err != nil {
+		t.Errorf("scheduler.wait() error = %v for a host without rate limit", err)
+	}
+
+	// Blocking schedulers wait until the quota is restored
+	s = newScheduler(true)
+	s.pause("api.github.com", &list.RateLimitError{Reset: time.Now().Add(50 * time.Millisecond)})
+	if err := s.wait(ctx, "api.github.com"); err != nil {
+		t.Errorf("scheduler.wait() error = %v", err)
+	}
+	if time.Until(s.until("api.github.com")) > 0 {
+		t.Errorf("scheduler.wait() returned before the quota was restored")
+	}
+
+	s.pause("api.github.com", &list.RateLimitError{Reset: later})
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := s.wait(canceled, "api.github.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("scheduler.wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestApp_resumeUpdate(t *testing.T) {
+	a, _ := New()
+	a.logger = zerolog.Nop()
+	a.cachedir = t.TempDir()
+	a.listers = map[string]list.Lister{
+		"a": fakeLister{},
+		"b": fakeLister{},
+		"c": fakeLister{},
+	}
+
+	which := []string{"c", "a", "b"}
+
+	if got := a.resumeUpdate(which); !reflect.DeepEqual(got, which) {
+		t.Errorf("resumeUpdate() without progress = %v, want %v", got, which)
+	}
+
+	if err := a.saveProgress(which, []string{"b", "removed"}); err != nil {
+		t.Fatalf("saveProgress() error = %v", err)
+	}
+
+	// Same distributions, in any order; removed ones are dropped
+	if got, want := a.resumeUpdate([]string{"a", "b", "c"}), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resumeUpdate() = %v, want %v", got, want)
+	}
+	// Other distributions
+	if got, want := a.resumeUpdate([]string{"a"}), []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resumeUpdate() for another update = %v, want %v", got, want)
+	}
+
+	// Nothing left
+	if err := a.saveProgress(which, nil); err != nil {
+		t.Fatalf("saveProgress() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(a.cachedir, progressFile)); !os.IsNotExist(err) {
+		t.Errorf("saveProgress() kept the progress file: %v", err)
+	}
+	if err := a.saveProgress(which, nil); err != nil {
+		t.Errorf("saveProgress() without progress file error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(a.cachedir, progressFile), []byte("{"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.resumeUpdate(which); !reflect.DeepEqual(got, which) {
+		t.Errorf("resumeUpdate() with invalid progress = %v, want %v", got, which)
+	}
+}
+
+func TestApp_updateLocally_rateLimits(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	releases := []list.Release{{Version: "1.0.0"}}
+
+	a, _ := New()
+	a.logger = zerolog.Nop()
+	a.cachedir = t.TempDir()
+	a.concurrency = 1
+	a.def = &Distributions{Sources: map[string]Sources{
+		"ok":        {List: list.List{Type: "static", URL: "https://ok.example.org/tool"}},
+		"close":     {List: list.List{Type: "static", URL: "https://close.example.org/tool"}},
+		"exhausted": {List: list.List{Type: "static", URL: "https://exhausted.example.org/tool"}},
+		"later":     {List: list.List{Type: "static", URL: "https://exhausted.example.org/other"}},
+	}}
+	a.listers = map[string]list.Lister{
+		"ok": fakeLister{releases: releases},
+		"close": fakeLister{releases: releases, err: &list.RateLimitError{
+			Err: list.ErrGithubRateLimitClose, Remaining: 2, Limit: 60, Reset: reset,
+		}},
+		"exhausted": fakeLister{err: &list.RateLimitError{
+			Err: list.ErrGithubRateLimited, Limit: 60, Reset: reset,
+		}},
+		"later": fakeLister{releases: releases},
+	}
+
+	which := []string{"ok", "close", "exhausted", "later"}
+	if err := a.updateLocally(which...); err != nil {
+		t.Fatalf("updateLocally() error = %v", err)
+	}
+
+	// Releases served close to the rate limit are kept
+	for _, d := range []string{"ok", "close"} {
+		if got := list.Versions(a.cache[d]); !reflect.DeepEqual(got, []string{"1.0.0"}) {
+			t.Errorf("versions for %q = %v, want [1.0.0]", d, got)
+		}
+	}
+
+	// Distributions on the exhausted host are left for later
+	for _, d := range []string{"exhausted", "later"} {
+		if len(a.cache[d]) != 0 {
+			t.Errorf("versions for %q = %v, want none", d, list.Versions(a.cache[d]))
+		}
+	}
+	if got, want := a.resumeUpdate(which), []string{"exhausted", "later"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resumeUpdate() = %v, want %v", got, want)
+	}
+}
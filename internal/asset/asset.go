@@ -0,0 +1,232 @@
+// Package asset selects the release asset to install for a platform from
+// asset names
+package asset
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/devops-works/binenv/internal/platform"
+)
+
+var (
+	// ErrNoMatch is returned when no asset matches the platform
+	ErrNoMatch = errors.New("no asset matches platform")
+
+	// ErrAmbiguous is returned when several assets match the platform equally
+	ErrAmbiguous = errors.New("several assets match platform")
+)
+
+// alias maps a name fragment to an OS or an arch
+type alias struct {
+	re    *regexp.Regexp
+	value string
+}
+
+func newAlias(fragment, value string) alias {
+	return alias{re: fragmentRegexp(fragment), value: value}
+}
+
+// osAliases are OS names found in asset names
+var osAliases = []alias{
+	newAlias("linux", "linux"),
+	newAlias("darwin", "darwin"),
+	newAlias("macos", "darwin"),
+	newAlias("osx", "darwin"),
+	newAlias("apple", "darwin"),
+	newAlias("mac", "darwin"),
+	newAlias("windows", "windows"),
+	newAlias("win64", "windows"),
+	newAlias("win32", "windows"),
+	newAlias("win", "windows"),
+	newAlias("freebsd", "freebsd"),
+	newAlias("openbsd", "openbsd"),
+	newAlias("netbsd", "netbsd"),
+}
+
+// archAliases are arch names found in asset names
+// Longer fragments are matched first and removed, so x86_64 is not also
+// seen as x86, nor arm64 as arm.
+var archAliases = []alias{
+	newAlias("x86_64", "amd64"),
+	newAlias("x86-64", "amd64"),
+	newAlias("amd64", "amd64"),
+	newAlias("x64", "amd64"),
+	newAlias("64bit", "amd64"),
+	newAlias("aarch64", "arm64"),
+	newAlias("arm64", "arm64"),
+	newAlias("armv8", "arm64"),
+	newAlias("i386", "386"),
+	newAlias("i686", "386"),
+	newAlias("386", "386"),
+	newAlias("x86", "386"),
+	newAlias("32bit", "386"),
+	newAlias("armv7l", "arm"),
+	newAlias("armv7", "arm"),
+	newAlias("armv6", "arm"),
+	newAlias("armhf", "arm"),
+	newAlias("armel", "arm"),
+	newAlias("arm", "arm"),
+	newAlias("ppc64le", "ppc64le"),
+	newAlias("s390x", "s390x"),
+	newAlias("riscv64", "riscv64"),
+}
+
+// universal matches arch fragments meaning any arch
+var universal = fragmentRegexp("(universal|all)")
+
+// linked matches statically linked builds, which work on any Linux
+// distribution
+var linked = fragmentRegexp("(musl|static)")
+
+// extensions maps supported file extensions to install types
+var extensions = []struct {
+	suffix  string
+	install string
+}{
+	{".tar.gz", "tgz"},
+	{".tgz", "tgz"},
+	{".tar.bz2", "tbz"},
+	{".tbz", "tbz"},
+	{".tar.xz", "tarxz"},
+	{".txz", "tarxz"},
+	{".zip", "zip"},
+	{".gz", "gzip"},
+	{".xz", "xz"},
+	{".exe", "direct"},
+}
+
+// ignored matches assets that are not installable (checksums, signatures,
+// packages, metadata...)
+var ignored = regexp.MustCompile(`(?i)(\.(sha1|sha256|sha512|sha256sum|sha512sum|md5|sum|sig|asc|pem|crt|cert|sbom|spdx|json|jsonl|txt|md|yaml|yml|deb|rpm|apk|msi|dmg|pkg|sh|ps1|pub|7z|zst|bz2|appimage|vsix|whl|snap)$|checksums|sbom|license|readme)`)
+
+// InstallType returns the install type for an asset, based on its extension
+// Assets without a known extension are installed directly.
+func InstallType(name string) string {
+	lower := strings.ToLower(name)
+	for _, e := range extensions {
+		if strings.HasSuffix(lower, e.suffix) {
+			return e.install
+		}
+	}
+
+	return "direct"
+}
+
+// Select returns the asset in names matching platform p for version
+// Assets are scored against OS and arch aliases: the asset OS must match, as
+// well as its arch if it has one. ErrAmbiguous is returned when the best
+// matches can not be told apart.
+func Select(names []string, version string, p platform.Platform) (string, error) {
+	type candidate struct {
+		name  string
+		score int
+	}
+
+	candidates := []candidate{}
+	for _, n := range names {
+		if s, ok := score(n, version, p); ok {
+			candidates = append(candidates, candidate{n, s})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w %s", ErrNoMatch, p)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	if len(candidates) > 1 && candidates[0].score == candidates[1].score {
+		tied := []string{}
+		for _, c := range candidates {
+			if c.score == candidates[0].score {
+				tied = append(tied, c.name)
+			}
+		}
+		return "", fmt.Errorf("%w %s: %s", ErrAmbiguous, p, strings.Join(tied, ", "))
+	}
+
+	return candidates[0].name, nil
+}
+
+// score returns how well name matches p, and false if it does not match
+func score(name, version string, p platform.Platform) (int, bool) {
+	lower := strings.ToLower(name)
+	if ignored.MatchString(lower) {
+		return 0, false
+	}
+
+	// Version numbers could be mistaken for arches (e.g. 0.386.0)
+	rest := lower
+	if version != "" {
+		rest = strings.ReplaceAll(rest, strings.ToLower(version), "_")
+	}
+
+	install := InstallType(lower)
+	exe := strings.HasSuffix(lower, ".exe")
+
+	s := 0
+
+	oses, rest := detect(rest, osAliases)
+	switch {
+	case oses[p.OS]:
+		s += 10
+	case len(oses) == 0 && p.OS == "windows" && exe:
+		// foo.exe is obviously for windows
+		s += 10
+	default:
+		// Either another OS, or none at all
+		return 0, false
+	}
+
+	arches, rest := detect(rest, archAliases)
+	switch {
+	case arches[p.Arch]:
+		s += 10
+	case len(arches) > 0:
+		return 0, false
+	case universal.MatchString(rest):
+		s += 5
+	}
+
+	if p.OS == "linux" && linked.MatchString(rest) {
+		s++
+	}
+
+	// Prefer the archive format native to the OS when several are published
+	switch {
+	case p.OS == "windows" && install == "direct" && !exe:
+		return 0, false
+	case p.OS == "windows" && install == "zip":
+		s += 2
+	case p.OS != "windows" && (install == "tgz" || install == "tbz" || install == "tarxz"):
+		s += 2
+	case install != "direct":
+		s++
+	}
+
+	return s, true
+}
+
+// detect returns values of aliases found in name, and name without them
+func detect(name string, aliases []alias) (map[string]bool, string) {
+	found := make(map[string]bool)
+	for _, a := range aliases {
+		if a.re.MatchString(name) {
+			found[a.value] = true
+			name = a.re.ReplaceAllString(name, "${1}_${2}")
+		}
+	}
+
+	return found, name
+}
+
+// fragmentRegexp matches f delimited by non alphanumeric characters
+func fragmentRegexp(f string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[^a-z0-9])` + f + `($|[^a-z0-9])`)
+}
@@ -0,0 +1,115 @@
+package asset
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/devops-works/binenv/internal/platform"
+)
+
+var (
+	// goreleaser style
+	goreleaser = []string{
+		"checksums.txt",
+		"tool_1.2.3_Darwin_arm64.tar.gz",
+		"tool_1.2.3_Darwin_x86_64.tar.gz",
+		"tool_1.2.3_Linux_arm64.tar.gz",
+		"tool_1.2.3_Linux_i386.tar.gz",
+		"tool_1.2.3_Linux_x86_64.tar.gz",
+		"tool_1.2.3_Windows_x86_64.zip",
+		"tool_1.2.3_linux_amd64.deb",
+	}
+
+	// rust style, with gnu and musl builds
+	rust = []string{
+		"tool-v0.386.0-aarch64-apple-darwin.tar.gz",
+		"tool-v0.386.0-x86_64-apple-darwin.tar.gz",
+		"tool-v0.386.0-x86_64-pc-windows-msvc.zip",
+		"tool-v0.386.0-x86_64-unknown-linux-gnu.tar.gz",
+		"tool-v0.386.0-x86_64-unknown-linux-musl.tar.gz",
+		"tool-v0.386.0-x86_64-unknown-linux-musl.tar.gz.sha256",
+		"tool-v0.386.0-arm-unknown-linux-gnueabihf.tar.gz",
+	}
+
+	// plain binaries
+	binaries = []string{
+		"tool-darwin-universal",
+		"tool-linux-amd64",
+		"tool-linux-arm64",
+		"tool-linux-armv7",
+		"tool.exe",
+	}
+)
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		version string
+		p       platform.Platform
+		want    string
+		wantErr error
+	}{
+		{name: "goreleaser linux", names: goreleaser, version: "1.2.3", p: platform.Platform{OS: "linux", Arch: "amd64"}, want: "tool_1.2.3_Linux_x86_64.tar.gz"},
+		{name: "goreleaser 386", names: goreleaser, version: "1.2.3", p: platform.Platform{OS: "linux", Arch: "386"}, want: "tool_1.2.3_Linux_i386.tar.gz"},
+		{name: "goreleaser darwin", names: goreleaser, version: "1.2.3", p: platform.Platform{OS: "darwin", Arch: "arm64"}, want: "tool_1.2.3_Darwin_arm64.tar.gz"},
+		{name: "goreleaser windows", names: goreleaser, version: "1.2.3", p: platform.Platform{OS: "windows", Arch: "amd64"}, want: "tool_1.2.3_Windows_x86_64.zip"},
+		{name: "goreleaser missing", names: goreleaser, version: "1.2.3", p: platform.Platform{OS: "freebsd", Arch: "amd64"}, wantErr: ErrNoMatch},
+		{name: "rust prefers musl", names: rust, version: "0.386.0", p: platform.Platform{OS: "linux", Arch: "amd64"}, want: "tool-v0.386.0-x86_64-unknown-linux-musl.tar.gz"},
+		{name: "rust version is not an arch", names: rust, version: "0.386.0", p: platform.Platform{OS: "linux", Arch: "386"}, wantErr: ErrNoMatch},
+		{name: "rust arm", names: rust, version: "0.386.0", p: platform.Platform{OS: "linux", Arch: "arm"}, want: "tool-v0.386.0-arm-unknown-linux-gnueabihf.tar.gz"},
+		{name: "rust darwin", names: rust, version: "0.386.0", p: platform.Platform{OS: "darwin", Arch: "amd64"}, want: "tool-v0.386.0-x86_64-apple-darwin.tar.gz"},
+		{name: "binary", names: binaries, p: platform.Platform{OS: "linux", Arch: "arm64"}, want: "tool-linux-arm64"},
+		{name: "binary arm", names: binaries, p: platform.Platform{OS: "linux", Arch: "arm"}, want: "tool-linux-armv7"},
+		{name: "binary universal", names: binaries, p: platform.Platform{OS: "darwin", Arch: "arm64"}, want: "tool-darwin-universal"},
+		{name: "binary windows", names: binaries, p: platform.Platform{OS: "windows", Arch: "amd64"}, want: "tool.exe"},
+		{
+			name:  "archive preferred",
+			names: []string{"tool-linux-amd64", "tool-linux-amd64.zip", "tool-linux-amd64.tar.gz"},
+			p:     platform.Platform{OS: "linux", Arch: "amd64"},
+			want:  "tool-linux-amd64.tar.gz",
+		},
+		{
+			name:    "ambiguous",
+			names:   []string{"tool-linux-amd64.tar.gz", "tool-server-linux-amd64.tar.gz"},
+			p:       platform.Platform{OS: "linux", Arch: "amd64"},
+			wantErr: ErrAmbiguous,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Select(tt.names, tt.version, tt.p)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Select() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstallType(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "tool_Linux_x86_64.tar.gz", want: "tgz"},
+		{name: "tool.TGZ", want: "tgz"},
+		{name: "tool.tar.xz", want: "tarxz"},
+		{name: "tool.tar.bz2", want: "tbz"},
+		{name: "tool.zip", want: "zip"},
+		{name: "tool.gz", want: "gzip"},
+		{name: "tool.exe", want: "direct"},
+		{name: "tool-linux-amd64", want: "direct"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InstallType(tt.name); got != tt.want {
+				t.Errorf("InstallType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+// Package auth resolves credentials used to list and fetch releases
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrMissingCredentials is returned when configured credentials can not be
+// found
+var ErrMissingCredentials = errors.New("missing credentials")
+
+// Auth types
+const (
+	TypeBearer = "bearer" // Authorization: Bearer <token>
+	TypeBasic  = "basic"  // Authorization: Basic <username:password>
+	TypeGithub = "github" // Authorization: token <token>
+	TypeGitlab = "gitlab" // PRIVATE-TOKEN: <token>
+	TypeNetrc  = "netrc"  // basic auth using the ~/.netrc entry for the host
+)
+
+// Auth describes how to authenticate against a source
+//
+// Token and Password should only be used in the credentials file; in
+// distributions files, use TokenEnv and PasswordEnv instead.
+type Auth struct {
+	Type        string `yaml:"type"`
+	Token       string `yaml:"token"`
+	TokenEnv    string `yaml:"token_env"`
+	Username    string `yaml:"username"`
+	UsernameEnv string `yaml:"username_env"`
+	Password    string `yaml:"password"`
+	PasswordEnv string `yaml:"password_env"`
+}
+
+// IsZero returns true if no authentication is configured
+func (a Auth) IsZero() bool {
+	return a == Auth{}
+}
+
+// kind returns a type, guessing it from the fields set when empty
+func (a Auth) kind() string {
+	switch {
+	case a.Type != "":
+		return a.Type
+	case a.Token != "" || a.TokenEnv != "":
+		return TypeBearer
+	default:
+		return TypeBasic
+	}
+}
+
+// Validate checks a type is supported
+func (a Auth) Validate() error {
+	if a.IsZero() {
+		return nil
+	}
+
+	switch a.kind() {
+	case TypeBearer, TypeBasic, TypeGithub, TypeGitlab, TypeNetrc:
+		return nil
+	}
+
+	return fmt.Errorf("unsupported auth type %q", a.Type)
+}
+
+// Credentials are resolved credentials
+type Credentials struct {
+	Type     string
+	Token    string
+	Username string
+	Password string
+}
+
+// IsZero returns true if there are no credentials
+func (c Credentials) IsZero() bool {
+	return c == Credentials{}
+}
+
+// Header returns headers to set on requests
+func (c Credentials) Header() map[string]string {
+	switch c.Type {
+	case TypeBearer:
+		return map[string]string{"Authorization": "Bearer " + c.Token}
+	case TypeGithub:
+		return map[string]string{"Authorization": "token " + c.Token}
+	case TypeGitlab:
+		return map[string]string{"PRIVATE-TOKEN": c.Token}
+	case TypeBasic:
+		auth := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+		return map[string]string{"Authorization": "Basic " + auth}
+	}
+
+	return map[string]string{}
+}
+
+// Basic returns a username and password (e.g. for registries, which accept
+// tokens as password)
+func (c Credentials) Basic() (string, string) {
+	if c.Type == TypeBasic {
+		return c.Username, c.Password
+	}
+	if c.Token != "" {
+		return "binenv", c.Token
+	}
+
+	return "", ""
+}
+
+// Resolver resolves Auth into Credentials
+//
+// It holds per-host credentials read from the credentials file, used when a
+// source has no auth configured, or when its configured credentials are
+// missing. A nil Resolver only uses the environment and ~/.netrc.
+type Resolver struct {
+	file  string
+	hosts map[string]Auth
+
+	netrcFile string
+	netrcOnce sync.Once
+	netrc     []netrcEntry
+	netrcErr  error
+}
+
+// credentialsFile is the credentials file format
+type credentialsFile struct {
+	Hosts map[string]Auth `yaml:"hosts"`
+}
+
+// NewResolver returns a resolver using per-host credentials from file
+// A missing file is not an error.
+func NewResolver(file string) (*Resolver, error) {
+	r := &Resolver{
+		file:      file,
+		hosts:     make(map[string]Auth),
+		netrcFile: netrcPath(),
+	}
+
+	yml, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("unable to read credentials file %s: %w", file, err)
+	}
+
+	c := credentialsFile{}
+	err = yaml.UnmarshalStrict(yml, &c)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse credentials file %s: %w", file, err)
+	}
+
+	for h, a := range c.Hosts {
+		if err := a.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid credentials for %s in %s: %w", h, file, err)
+		}
+		r.hosts[h] = a
+	}
+
+	return r, nil
+}
+
+// Resolve returns credentials to use for host according to a
+// When a is empty, or when its credentials are missing, credentials from the
+// credentials file for host are used, if any.
+func (r *Resolver) Resolve(a Auth, host string) (Credentials, error) {
+	if a.IsZero() {
+		if h, ok := r.host(host); ok {
+			return r.resolve(h, host)
+		}
+		return Credentials{}, nil
+	}
+
+	c, err := r.resolve(a, host)
+	if errors.Is(err, ErrMissingCredentials) {
+		if h, ok := r.host(host); ok {
+			return r.resolve(h, host)
+		}
+	}
+
+	return c, err
+}
+
+// Authorize sets credentials for req according to a
+// It returns false if there are no credentials for the request.
+func (r *Resolver) Authorize(req *http.Request, a Auth) (bool, error) {
+	c, err := r.Resolve(a, req.URL.Host)
+	if err != nil {
+		return false, err
+	}
+	if c.IsZero() {
+		return false, nil
+	}
+
+	for k, v := range c.Header() {
+		req.Header.Set(k, v)
+	}
+
+	return true, nil
+}
+
+// apiHosts maps API hosts to the host users know the forge by, so
+// credentials for github.com are also used for its API
+var apiHosts = map[string]string{
+	"api.github.com": "github.com",
+}
+
+// host returns the credentials file entry for host, trying without the port
+// if needed
+func (r *Resolver) host(host string) (Auth, bool) {
+	if r == nil {
+		return Auth{}, false
+	}
+
+	if a, ok := r.hosts[host]; ok {
+		return a, true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		a, ok := r.hosts[h]
+		return a, ok
+	}
+	if h, ok := apiHosts[host]; ok {
+		a, ok := r.hosts[h]
+		return a, ok
+	}
+
+	return Auth{}, false
+}
+
+func (r *Resolver) resolve(a Auth, host string) (Credentials, error) {
+	if err := a.Validate(); err != nil {
+		return Credentials{}, err
+	}
+
+	kind := a.kind()
+
+	switch kind {
+	case TypeNetrc:
+		login, password, err := r.lookupNetrc(host)
+		if err != nil {
+			return Credentials{}, err
+		}
+		return Credentials{Type: TypeBasic, Username: login, Password: password}, nil
+	case TypeBasic:
+		username, err := value(a.Username, a.UsernameEnv, "username", host)
+		if err != nil {
+			return Credentials{}, err
+		}
+		password, err := value(a.Password, a.PasswordEnv, "password", host)
+		if err != nil {
+			return Credentials{}, err
+		}
+		return Credentials{Type: TypeBasic, Username: username, Password: password}, nil
+	}
+
+	token, err := value(a.Token, a.TokenEnv, "token", host)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return Credentials{Type: kind, Token: token}, nil
+}
+
+// value returns literal, or the content of env var env
+func value(literal, env, what, host string) (string, error) {
+	if literal != "" {
+		return literal, nil
+	}
+	if env == "" {
+		return "", fmt.Errorf("%w for %s: no %s configured", ErrMissingCredentials, host, what)
+	}
+
+	v := os.Getenv(env)
+	if v == "" {
+		return "", fmt.Errorf("%w for %s: %s env var %s is not defined; did you export it ?", ErrMissingCredentials, host, what, env)
+	}
+
+	return v, nil
+}
+
+func (r *Resolver) lookupNetrc(host string) (string, string, error) {
+	file := netrcPath()
+	var (
+		entries []netrcEntry
+		err     error
+	)
+
+	if r == nil {
+		entries, err = readNetrc(file)
+	} else {
+		r.netrcOnce.Do(func() {
+			r.netrc, r.netrcErr = readNetrc(r.netrcFile)
+		})
+		file, entries, err = r.netrcFile, r.netrc, r.netrcErr
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+
+	for _, e := range entries {
+		if e.machine == name || e.machine == "" {
+			return e.login, e.password, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("%w for %s: no entry found in %s", ErrMissingCredentials, host, file)
+}
+
+// netrcPath returns $NETRC, or ~/.netrc
+func netrcPath() string {
+	if f := os.Getenv("NETRC"); f != "" {
+		return f
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".netrc"
+	}
+
+	return filepath.Join(home, ".netrc")
+}
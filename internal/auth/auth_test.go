@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+
+	creds := filepath.Join(dir, "credentials.yaml")
+	err := os.WriteFile(creds, []byte(`hosts:
+  gitlab.example.org:
+    type: gitlab
+    token: from-file
+  registry.example.org:5000:
+    username: robot
+    password: secret
+  netrc.example.org:
+    type: netrc
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	netrc := filepath.Join(dir, "netrc")
+	err = os.WriteFile(netrc, []byte(`machine netrc.example.org
+  login alice
+  password wonderland
+default login anonymous password guest
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NETRC", netrc)
+
+	t.Setenv("BINENV_TEST_TOKEN", "from-env")
+	t.Setenv("BINENV_TEST_EMPTY", "")
+
+	r, err := NewResolver(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		auth    Auth
+		host    string
+		want    Credentials
+		missing bool
+	}{
+		{
+			name: "nothing configured",
+			host: "github.com",
+		},
+		{
+			name: "bearer token from env",
+			auth: Auth{TokenEnv: "BINENV_TEST_TOKEN"},
+			host: "github.com",
+			want: Credentials{Type: TypeBearer, Token: "from-env"},
+		},
+		{
+			name: "github token",
+			auth: Auth{Type: TypeGithub, TokenEnv: "BINENV_TEST_TOKEN"},
+			host: "github.com",
+			want: Credentials{Type: TypeGithub, Token: "from-env"},
+		},
+		{
+			name:    "missing env var",
+			auth:    Auth{Type: TypeGithub, TokenEnv: "BINENV_TEST_EMPTY"},
+			host:    "github.com",
+			missing: true,
+		},
+		{
+			name: "missing env var falls back to credentials file",
+			auth: Auth{Type: TypeGitlab, TokenEnv: "BINENV_TEST_EMPTY"},
+			host: "gitlab.example.org",
+			want: Credentials{Type: TypeGitlab, Token: "from-file"},
+		},
+		{
+			name: "credentials file",
+			host: "gitlab.example.org",
+			want: Credentials{Type: TypeGitlab, Token: "from-file"},
+		},
+		{
+			name: "credentials file with port",
+			host: "registry.example.org:5000",
+			want: Credentials{Type: TypeBasic, Username: "robot", Password: "secret"},
+		},
+		{
+			name: "credentials file using netrc",
+			host: "netrc.example.org",
+			want: Credentials{Type: TypeBasic, Username: "alice", Password: "wonderland"},
+		},
+		{
+			name: "netrc default",
+			auth: Auth{Type: TypeNetrc},
+			host: "other.example.org",
+			want: Credentials{Type: TypeBasic, Username: "anonymous", Password: "guest"},
+		},
+		{
+			name:    "basic without password",
+			auth:    Auth{Type: TypeBasic, Username: "bob", PasswordEnv: "BINENV_TEST_EMPTY"},
+			host:    "example.org",
+			missing: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Resolve(tt.auth, tt.host)
+			if tt.missing {
+				if !errors.Is(err, ErrMissingCredentials) {
+					t.Errorf("Resolver.Resolve() error = %v, want %v", err, ErrMissingCredentials)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolver.Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolver.Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Resolve_apiHost(t *testing.T) {
+	creds := filepath.Join(t.TempDir(), "credentials.yaml")
+	err := os.WriteFile(creds, []byte("hosts:\n  github.com:\n    token: from-file\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewResolver(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Credentials{Type: TypeBearer, Token: "from-file"}
+	for _, host := range []string{"github.com", "api.github.com"} {
+		got, err := r.Resolve(Auth{}, host)
+		if err != nil {
+			t.Fatalf("Resolver.Resolve(%q) error = %v", host, err)
+		}
+		if got != want {
+			t.Errorf("Resolver.Resolve(%q) = %+v, want %+v", host, got, want)
+		}
+	}
+}
+
+func TestNewResolver_invalid(t *testing.T) {
+	creds := filepath.Join(t.TempDir(), "credentials.yaml")
+	err := os.WriteFile(creds, []byte("hosts:\n  example.org:\n    type: kerberos\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewResolver(creds); err == nil {
+		t.Errorf("NewResolver() accepted an unsupported auth type")
+	}
+
+	if _, err := NewResolver(filepath.Join(t.TempDir(), "missing.yaml")); err != nil {
+		t.Errorf("NewResolver() error = %v for a missing file", err)
+	}
+}
+
+func TestCredentials_Header(t *testing.T) {
+	tests := []struct {
+		creds Credentials
+		want  map[string]string
+	}{
+		{Credentials{}, map[string]string{}},
+		{Credentials{Type: TypeBearer, Token: "t"}, map[string]string{"Authorization": "Bearer t"}},
+		{Credentials{Type: TypeGithub, Token: "t"}, map[string]string{"Authorization": "token t"}},
+		{Credentials{Type: TypeGitlab, Token: "t"}, map[string]string{"PRIVATE-TOKEN": "t"}},
+		{Credentials{Type: TypeBasic, Username: "u", Password: "p"}, map[string]string{"Authorization": "Basic dTpw"}},
+	}
+	for _, tt := range tests {
+		if got := tt.creds.Header(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Credentials.Header() = %v, want %v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// netrcEntry is a machine (or default, with an empty machine) entry
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+}
+
+// readNetrc reads machine entries from file; the default entry, if any, is
+// returned last
+func readNetrc(file string) ([]netrcEntry, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to read %s: %w", file, err)
+	}
+
+	return parseNetrc(content), nil
+}
+
+func parseNetrc(content []byte) []netrcEntry {
+	var (
+		entries []netrcEntry
+		def     *netrcEntry
+		current *netrcEntry
+	)
+
+	s := bufio.NewScanner(bytes.NewReader(content))
+	s.Split(bufio.ScanWords)
+
+	next := func() string {
+		if s.Scan() {
+			return s.Text()
+		}
+		return ""
+	}
+
+	flush := func() {
+		if current != nil && current.machine != "" {
+			entries = append(entries, *current)
+		}
+		current = nil
+	}
+
+	for s.Scan() {
+		switch s.Text() {
+		case "machine":
+			flush()
+			current = &netrcEntry{machine: next()}
+		case "default":
+			flush()
+			def = &netrcEntry{}
+			current = def
+		case "login":
+			if current != nil {
+				current.login = next()
+			}
+		case "password":
+			if current != nil {
+				current.password = next()
+			}
+		case "account":
+			next()
+		case "macdef":
+			// Macros end with an empty line; they are not supported and
+			// end parsing
+			flush()
+			return appendDefault(entries, def)
+		}
+	}
+	flush()
+
+	return appendDefault(entries, def)
+}
+
+func appendDefault(entries []netrcEntry, def *netrcEntry) []netrcEntry {
+	if def != nil {
+		entries = append(entries, *def)
+	}
+
+	return entries
+}
@@ -0,0 +1,173 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/tpl"
+)
+
+var (
+	// ErrChecksumMismatch is returned when a downloaded file does not match
+	// its published checksum
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrChecksumNotFound is returned when the checksum file does not contain
+	// an entry for the downloaded asset
+	ErrChecksumNotFound = errors.New("checksum not found")
+)
+
+// Checksum contains published checksums configuration
+type Checksum struct {
+	URL       string `yaml:"url"`       // checksum file URL template
+	Algorithm string `yaml:"algorithm"` // md5, sha1, sha256 or sha512; guessed when empty
+}
+
+// BSD style entries: SHA256 (file.tar.gz) = abcd...
+var bsdChecksumRe = regexp.MustCompile(`^([A-Za-z0-9-]+) \((.+)\) = ([0-9a-fA-F]+)$`)
+
+// hexRe matches hex encoded hashes
+var hexRe = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// IsZero returns true if no checksum has been configured
+func (c Checksum) IsZero() bool {
+	return c.URL == ""
+}
+
+// verify checks that file matches the checksum published for asset
+//
+// asset is the name of the downloaded artifact (e.g.
+// terraform_1.0.0_linux_amd64.zip) and is used to find the proper line in
+// multi-entries files like checksums.txt or SHA256SUMS.
+func (c Checksum) verify(ctx context.Context, opts options, file, asset string, args tpl.Args) error {
+	logger := zerolog.Ctx(ctx).With().Str("func", "Checksum.verify").Logger()
+
+	url, err := args.Render(c.URL)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug().Msgf("fetching checksums for %s at %s", asset, url)
+
+	body, err := getFile(ctx, opts, url)
+	if err != nil {
+		return fmt.Errorf("unable to download checksums: %w", err)
+	}
+
+	want, err := findChecksum(body, asset)
+	if err != nil {
+		return fmt.Errorf("%w for %s in %s", err, asset, url)
+	}
+
+	h, err := newHash(c.Algorithm, want)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	if _, err := io.Copy(h, fd); err != nil {
+		return err
+	}
+
+	got := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, asset, want, got)
+	}
+
+	logger.Debug().Msgf("checksum for %s verified (%s)", asset, got)
+
+	return nil
+}
+
+// findChecksum returns the hex encoded checksum for asset
+//
+// Supported formats are the ones generated by sha*sum (`hash  file` or
+// `hash *file`), the BSD style format (`SHA256 (file) = hash`), and files
+// containing only a hash (e.g. per asset .sha256 files).
+func findChecksum(content []byte, asset string) (string, error) {
+	// Files holding nothing but a hash match any asset
+	if fields := strings.Fields(string(content)); len(fields) == 1 && hexRe.MatchString(fields[0]) {
+		return strings.ToLower(fields[0]), nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if m := bsdChecksumRe.FindStringSubmatch(line); m != nil {
+			if path.Base(m[2]) == asset {
+				return strings.ToLower(m[3]), nil
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name := strings.TrimPrefix(fields[len(fields)-1], "*")
+		if path.Base(name) == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", ErrChecksumNotFound
+}
+
+// newHash returns a hash for the algorithm
+// If algo is empty, we try to guess it from the expected checksum length
+func newHash(algo, sum string) (hash.Hash, error) {
+	if algo == "" {
+		switch len(sum) {
+		case 32:
+			algo = "md5"
+		case 40:
+			algo = "sha1"
+		case 64:
+			algo = "sha256"
+		case 128:
+			algo = "sha512"
+		}
+	}
+
+	switch strings.ToLower(algo) {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+}
@@ -0,0 +1,84 @@
+package fetch
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_findChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		asset   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "sha256sum format",
+			content: "aaaa  foo_linux_amd64.tar.gz\nbbbb  foo_darwin_amd64.tar.gz\n",
+			asset:   "foo_darwin_amd64.tar.gz",
+			want:    "bbbb",
+		},
+		{
+			name:    "binary mode",
+			content: "AAAA *foo_linux_amd64.tar.gz\n",
+			asset:   "foo_linux_amd64.tar.gz",
+			want:    "aaaa",
+		},
+		{
+			name:    "with directory",
+			content: "aaaa  dist/foo_linux_amd64.tar.gz\n",
+			asset:   "foo_linux_amd64.tar.gz",
+			want:    "aaaa",
+		},
+		{
+			name:    "bsd format",
+			content: "SHA256 (foo_linux_amd64.tar.gz) = cccc\n",
+			asset:   "foo_linux_amd64.tar.gz",
+			want:    "cccc",
+		},
+		{
+			name:    "single hash",
+			content: "dddd\n",
+			asset:   "foo_linux_amd64.tar.gz",
+			want:    "dddd",
+		},
+		{
+			name:    "single hash with blank lines",
+			content: "\nDDDD\n\n",
+			asset:   "foo_linux_amd64.tar.gz",
+			want:    "dddd",
+		},
+		{
+			name:    "single hash among other lines",
+			content: "dddd\naaaa  foo_darwin_amd64.tar.gz\n",
+			asset:   "foo_linux_amd64.tar.gz",
+			wantErr: ErrChecksumNotFound,
+		},
+		{
+			name:    "single word not a hash",
+			content: "<html>\n",
+			asset:   "foo_linux_amd64.tar.gz",
+			wantErr: ErrChecksumNotFound,
+		},
+		{
+			name:    "not found",
+			content: "aaaa  foo_linux_amd64.tar.gz\n",
+			asset:   "foo_linux_arm64.tar.gz",
+			wantErr: ErrChecksumNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findChecksum([]byte(tt.content), tt.asset)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("findChecksum() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("findChecksum() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,71 +3,118 @@ package fetch
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
 	"os"
-	"runtime"
 
 	"github.com/rs/zerolog"
-	"github.com/schollz/progressbar/v3"
 
+	"github.com/devops-works/binenv/internal/auth"
 	"github.com/devops-works/binenv/internal/mapping"
+	"github.com/devops-works/binenv/internal/platform"
+	"github.com/devops-works/binenv/internal/store"
 	"github.com/devops-works/binenv/internal/tpl"
 )
 
 // Download handles direct binary releases
 type Download struct {
-	url     string
-	headers map[string]string
+	url         string
+	auth        auth.Auth
+	credentials *auth.Resolver
+	store       *store.Store
+	transfer    transfer
+	verify      func(string) error
 }
 
 // Fetch gets the package and returns location of downloaded file
 func (d Download) Fetch(ctx context.Context, dist, v string, mapper mapping.Mapper) (string, error) {
+	_, file, err := d.fetchFor(ctx, fmt.Sprintf("fetching %s version %s", dist, v), v, mapper, platform.Current())
+	return file, err
+}
+
+func (d Download) fetchFor(ctx context.Context, desc, v string, mapper mapping.Mapper, p platform.Platform) (string, string, error) {
 	logger := zerolog.Ctx(ctx).With().Str("func", "Download.Fetch").Logger()
 
-	args := tpl.New(v, mapper)
+	args := tpl.NewFor(v, mapper, p)
 
 	url, err := args.Render(d.url)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	logger.Debug().Msgf("fetching version %q for arch %q and OS %q at %s", v, runtime.GOARCH, runtime.GOOS, url)
+	logger.Debug().Msgf("fetching version %q for arch %q and OS %q at %s", v, p.Arch, p.OS, url)
+
+	file, err := d.download(ctx, url, desc)
+	return url, file, err
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// download gets url, using the store if any, and returns the location of the
+// downloaded file
+func (d Download) download(ctx context.Context, url, desc string) (string, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "Download.download").Logger()
+
+	if d.store != nil {
+		defer d.store.Lock(url)()
+
+		if file, ok := d.store.Lookup(url); ok {
+			err := check(d.verify, file)
+			if err == nil {
+				logger.Debug().Msgf("using cached artifact %s for %s", file, url)
+				return file, nil
+			}
+			logger.Debug().Err(err).Msgf("cached artifact %s for %s can not be used", file, url)
+		}
+	}
+
+	headers, err := d.headers(url)
 	if err != nil {
 		return "", err
 	}
 
-	for k, v := range d.headers {
-		req.Header.Add(k, v)
+	// When using a store, partial downloads interrupted or failing with
+	// transient errors are kept so they can be resumed later
+	var dst *os.File
+	if d.store != nil {
+		dst, err = d.store.PartialFile(url)
+	} else {
+		dst, err = os.CreateTemp("", "binenv")
+	}
+	if err != nil {
+		return "", err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	err = d.transfer.get(ctx, url, headers, dst, desc)
+	dst.Close()
 	if err != nil {
+		if d.store == nil || !resumable(ctx, err) {
+			removePartial(dst.Name())
+		}
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unable to download binary at %s: %s", url, resp.Status)
+	// Bad content must not be kept, nor resumed from
+	if err := check(d.verify, dst.Name()); err != nil {
+		removePartial(dst.Name())
+		return "", err
 	}
 
-	tmpfile, err := os.CreateTemp("", v)
-	if err != nil {
-		logger.Fatal().Err(err)
+	if d.store == nil {
+		return dst.Name(), nil
 	}
 
-	defer tmpfile.Close()
+	return d.store.Put(url, dst.Name())
+}
 
-	bar := progressbar.DefaultBytes(
-		resp.ContentLength,
-		fmt.Sprintf("fetching %s version %s", dist, v),
-	)
-	io.Copy(io.MultiWriter(tmpfile, bar), resp.Body)
+// headers returns the headers required to download u
+func (d Download) headers(u string) (map[string]string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
 
-	// Write the body to file
-	_, err = io.Copy(tmpfile, resp.Body)
+	c, err := d.credentials.Resolve(d.auth, parsed.Host)
+	if err != nil {
+		return nil, err
+	}
 
-	return tmpfile.Name(), err
+	return c.Header(), nil
 }
@@ -0,0 +1,192 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devops-works/binenv/internal/auth"
+	"github.com/devops-works/binenv/internal/platform"
+	"github.com/devops-works/binenv/internal/store"
+)
+
+func TestDownload_auth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, "nope", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("private release"))
+	}))
+	defer ts.Close()
+
+	fetch := func(a auth.Auth) error {
+		f, err := Fetch{URL: ts.URL + "/tool-{{ .Version }}", Auth: a}.Factory(WithClient(ts.Client()))
+		if err != nil {
+			return err
+		}
+		file, err := f.Fetch(context.Background(), "tool", "1.0.0", nil)
+		if err == nil {
+			os.Remove(file)
+		}
+		return err
+	}
+
+	a := auth.Auth{Type: auth.TypeGithub, TokenEnv: "BINENV_TEST_GITHUB_TOKEN"}
+
+	t.Setenv("BINENV_TEST_GITHUB_TOKEN", "")
+	if err := fetch(a); !errors.Is(err, auth.ErrMissingCredentials) {
+		t.Errorf("Download.Fetch() error = %v, want %v", err, auth.ErrMissingCredentials)
+	}
+
+	t.Setenv("BINENV_TEST_GITHUB_TOKEN", "secret")
+	if err := fetch(a); err != nil {
+		t.Errorf("Download.Fetch() error = %v", err)
+	}
+
+	if _, err := (Fetch{Auth: auth.Auth{Type: "kerberos"}}).Factory(); err == nil {
+		t.Errorf("Fetch.Factory() accepted an unsupported auth type")
+	}
+}
+
+func TestFetch_Available(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tool-1.0.0-linux-amd64":
+			w.Write([]byte("release"))
+		case "/head-not-allowed":
+			http.Error(w, "nope", http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		fetch   Fetch
+		arch    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "available", fetch: Fetch{URL: ts.URL + "/tool-{{ .Version }}-{{ .OS }}-{{ .Arch }}"}, arch: "amd64", want: true},
+		{name: "missing", fetch: Fetch{URL: ts.URL + "/tool-{{ .Version }}-{{ .OS }}-{{ .Arch }}"}, arch: "arm64", want: false},
+		{name: "head not allowed", fetch: Fetch{URL: ts.URL + "/head-not-allowed"}, arch: "amd64", wantErr: true},
+		{name: "oci", fetch: Fetch{Type: "oci", URL: "registry.example.org/tool:{{ .Version }}"}, arch: "amd64", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := platform.Platform{OS: "linux", Arch: tt.arch}
+			got, err := tt.fetch.Available(context.Background(), "1.0.0", nil, p, WithClient(ts.Client()))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetch.Available() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Fetch.Available() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Downloads of missing releases report ErrNotFound
+	f, err := Fetch{URL: ts.URL + "/tool-{{ .Version }}-missing"}.Factory(WithClient(ts.Client()))
+	if err != nil {
+		t.Fatalf("Fetch.Factory() error = %v", err)
+	}
+	if _, err := f.Fetch(context.Background(), "tool", "1.0.0", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Download.Fetch() error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestDownload_verify(t *testing.T) {
+	content := "good release"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer ts.Close()
+
+	s := store.New(t.TempDir(), 0750)
+	verify := func(file string) error {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if string(b) != "good release" {
+			return errors.New("bad release")
+		}
+		return nil
+	}
+
+	fetch := func(verify func(string) error) (string, error) {
+		f, err := Fetch{URL: ts.URL + "/tool-{{ .Version }}"}.Factory(WithClient(ts.Client()), WithStore(s), WithVerify(verify))
+		if err != nil {
+			return "", err
+		}
+		return f.Fetch(context.Background(), "tool", "1.0.0", nil)
+	}
+	url := ts.URL + "/tool-1.0.0"
+
+	// Bad content is not stored
+	content = "bad release"
+	if _, err := fetch(verify); err == nil {
+		t.Fatalf("Download.Fetch() accepted a bad release")
+	}
+	if _, ok := s.Lookup(url); ok {
+		t.Errorf("Download.Fetch() stored a bad release")
+	}
+
+	// Cached content failing verification is fetched again
+	if _, err := fetch(nil); err != nil {
+		t.Fatalf("Download.Fetch() error = %v", err)
+	}
+	content = "good release"
+	file, err := fetch(verify)
+	if err != nil {
+		t.Fatalf("Download.Fetch() error = %v", err)
+	}
+	if b, _ := os.ReadFile(file); string(b) != content {
+		t.Errorf("Download.Fetch() = %q, want %q", b, content)
+	}
+	if stored, _ := s.Lookup(url); stored != file {
+		t.Errorf("Download.Fetch() = %s, want stored %s", file, stored)
+	}
+}
+
+func TestDownload_partial(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	dir := t.TempDir()
+	s := store.New(dir, 0750)
+	f, err := Fetch{URL: ts.URL + "/tool-{{ .Version }}"}.Factory(WithClient(ts.Client()), WithStore(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partials := func() []string {
+		matches, _ := filepath.Glob(filepath.Join(dir, "tmp", "*"))
+		return matches
+	}
+
+	// Interrupted transfers can be resumed
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.Fetch(ctx, "tool", "1.0.0", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Download.Fetch() error = %v, want %v", err, context.Canceled)
+	}
+	if len(partials()) != 1 {
+		t.Errorf("Download.Fetch() left %v, want interrupted partial download", partials())
+	}
+
+	// Transfers failing for good leave nothing behind
+	if _, err := f.Fetch(context.Background(), "tool", "1.0.0", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Download.Fetch() error = %v, want %v", err, ErrNotFound)
+	}
+	if got := partials(); len(got) != 0 {
+		t.Errorf("Download.Fetch() left %v", got)
+	}
+}
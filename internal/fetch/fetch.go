@@ -3,9 +3,18 @@ package fetch
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 
+	"github.com/devops-works/binenv/internal/auth"
 	"github.com/devops-works/binenv/internal/mapping"
+	"github.com/devops-works/binenv/internal/oci"
+	"github.com/devops-works/binenv/internal/platform"
+	"github.com/devops-works/binenv/internal/store"
+	"github.com/devops-works/binenv/internal/tpl"
 )
 
 // Fetcher should implement fetching a release from a version
@@ -14,33 +23,376 @@ type Fetcher interface {
 	Fetch(ctx context.Context, dist, version string, mapper mapping.Mapper) (string, error)
 }
 
+// platformFetcher is implemented by fetchers able to fetch releases for any
+// platform (e.g. to export them)
+type platformFetcher interface {
+	// fetchFor fetches version for platform p and returns the key under
+	// which the release is stored, and its location
+	fetchFor(ctx context.Context, desc, version string, mapper mapping.Mapper, p platform.Platform) (string, string, error)
+}
+
 // Fetch contains fetch configuration
 type Fetch struct {
-	Type     string `yaml:"type"`
-	URL      string `yaml:"url"`
-	TokenEnv string `yaml:"token_env"`
+	Type string `yaml:"type"` // download (default), file or oci
+	URL  string `yaml:"url"`  // URL, path or OCI reference template
+	// Auto selects the release asset matching the platform, using URL only
+	// when no asset matches unambiguously; implied when URL is empty
+	Auto bool `yaml:"auto"`
+	// TokenEnv is a shortcut for a gitlab auth (or a registry token for
+	// oci); ignored when Auth is set
+	TokenEnv  string    `yaml:"token_env"`
+	Auth      auth.Auth `yaml:"auth"`
+	Layer     string    `yaml:"layer"` // oci: title of the layer to fetch
+	Checksum  Checksum  `yaml:"checksum"`
+	Signature Signature `yaml:"signature"`
+}
+
+// options holds dependencies shared by fetchers
+type options struct {
+	store       *store.Store
+	client      *http.Client
+	credentials *auth.Resolver
+	// auth is set internally to authenticate getFile requests
+	auth   auth.Auth
+	verify func(file string) error
+}
+
+// Option configures fetchers returned by Factory
+type Option func(*options)
+
+// WithStore makes fetchers look for artifacts in s before downloading them,
+// and save downloaded artifacts in s
+func WithStore(s *store.Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
+// WithClient sets the HTTP client used by fetchers
+func WithClient(c *http.Client) Option {
+	return func(o *options) {
+		o.client = c
+	}
+}
+
+// WithCredentials sets the resolver used to find credentials
+func WithCredentials(r *auth.Resolver) Option {
+	return func(o *options) {
+		o.credentials = r
+	}
+}
+
+// WithVerify makes fetchers check releases with fn before saving them in the
+// store or returning them
+// Releases failing the check are discarded, and cached ones are fetched again.
+func WithVerify(fn func(file string) error) Option {
+	return func(o *options) {
+		o.verify = fn
+	}
+}
+
+func newOptions(o ...Option) options {
+	opts := options{
+		client: http.DefaultClient,
+	}
+	for _, f := range o {
+		f(&opts)
+	}
+
+	return opts
 }
 
 // Factory returns instances that comply to Fetcher interface
-func (r Fetch) Factory() (Fetcher, error) {
+// Credentials are resolved when fetching, so missing ones are reported then.
+func (r Fetch) Factory(o ...Option) (Fetcher, error) {
+	opts := newOptions(o...)
+
+	a := r.authConfig()
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
 	switch r.Type {
-	// case "download":
-	// 	return Download{
-	// 		url: r.URL,
-	// 	}
-	default:
-		headers := map[string]string{}
-		if r.TokenEnv != "" {
-			token := os.Getenv(r.TokenEnv)
-			if token == "" {
-				return nil, fmt.Errorf("token env var %s is not defined; did you export it ?", r.TokenEnv)
+	case "", "download":
+		return Download{
+			url:         r.URL,
+			auth:        a,
+			credentials: opts.credentials,
+			store:       opts.store,
+			transfer:    newTransfer(opts.client),
+			verify:      opts.verify,
+		}, nil
+	case "file":
+		return File{
+			path:   r.URL,
+			store:  opts.store,
+			verify: opts.verify,
+		}, nil
+	case "oci":
+		credentials := func(registry string) (string, string, error) {
+			c, err := opts.credentials.Resolve(a, registry)
+			if err != nil {
+				return "", "", err
 			}
-			headers["PRIVATE-TOKEN"] = token
+			username, password := c.Basic()
+			return username, password, nil
 		}
 
-		return Download{
-			url:     r.URL,
-			headers: headers,
+		return OCI{
+			ref:      r.URL,
+			layer:    r.Layer,
+			registry: oci.NewClient(opts.client, credentials),
+			store:    opts.store,
+			transfer: newTransfer(opts.client),
+			verify:   opts.verify,
 		}, nil
 	}
+
+	return nil, fmt.Errorf("unknown fetch type %q", r.Type)
+}
+
+// authConfig returns the configured auth, falling back to TokenEnv
+func (r Fetch) authConfig() auth.Auth {
+	if !r.Auth.IsZero() || r.TokenEnv == "" {
+		return r.Auth
+	}
+
+	// Registries accept tokens as password with any username
+	if r.Type == "oci" {
+		return auth.Auth{Type: auth.TypeBasic, Username: "binenv", PasswordEnv: r.TokenEnv}
+	}
+
+	return auth.Auth{Type: auth.TypeGitlab, TokenEnv: r.TokenEnv}
+}
+
+// Automatic returns true if the release asset to fetch is selected from the
+// release assets
+func (r Fetch) Automatic() bool {
+	if r.Type != "" && r.Type != "download" {
+		return false
+	}
+
+	return r.Auto || r.URL == ""
+}
+
+// Verifiable returns true if published checksums or signatures are
+// configured
+func (r Fetch) Verifiable() bool {
+	return !r.Checksum.IsZero() || !r.Signature.IsZero()
+}
+
+// Verify checks the file downloaded for version on platform p against the
+// published checksums and signatures, if any
+func (r Fetch) Verify(ctx context.Context, file, version string, mapper mapping.Mapper, p platform.Platform, o ...Option) error {
+	opts := newOptions(o...)
+	opts.auth = r.authConfig()
+	args := tpl.NewFor(version, mapper, p)
+
+	if !r.Signature.IsZero() {
+		err := r.Signature.verify(ctx, opts, file, args)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Checksum.IsZero() {
+		return nil
+	}
+
+	asset, err := r.asset(args)
+	if err != nil {
+		return err
+	}
+
+	return r.Checksum.verify(ctx, opts, file, asset, args)
+}
+
+// Mirror saves in the store everything needed to install version on platform
+// p offline: the release and its published checksums and signatures
+// It returns the keys saved.
+func (r Fetch) Mirror(ctx context.Context, dist, version string, mapper mapping.Mapper, p platform.Platform, o ...Option) ([]string, error) {
+	opts := newOptions(o...)
+	if opts.store == nil {
+		return nil, fmt.Errorf("no store to mirror %s to", dist)
+	}
+	opts.auth = r.authConfig()
+
+	f, err := r.Factory(o...)
+	if err != nil {
+		return nil, err
+	}
+	pf, ok := f.(platformFetcher)
+	if !ok {
+		return nil, fmt.Errorf("%q fetch method can not be mirrored", r.Type)
+	}
+
+	key, _, err := pf.fetchFor(ctx, fmt.Sprintf("fetching %s version %s for %s", dist, version, p), version, mapper, p)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{key}
+	args := tpl.NewFor(version, mapper, p)
+
+	for _, t := range []string{r.Signature.URL, r.Checksum.URL} {
+		if t == "" {
+			continue
+		}
+		u, err := args.Render(t)
+		if err != nil {
+			return nil, err
+		}
+		_, err = getFile(ctx, opts, u)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, u)
+	}
+
+	return keys, nil
+}
+
+// Available tells whether version exists for platform p, sending a HEAD
+// request to the release URL
+// An error is returned when this can not be told, e.g. for fetch methods
+// other than download or when the server does not answer HEAD requests.
+func (r Fetch) Available(ctx context.Context, version string, mapper mapping.Mapper, p platform.Platform, o ...Option) (bool, error) {
+	if r.Type != "" && r.Type != "download" {
+		return false, fmt.Errorf("availability of %q fetch method releases can not be probed", r.Type)
+	}
+
+	opts := newOptions(o...)
+
+	u, err := tpl.NewFor(version, mapper, p).Render(r.URL)
+	if err != nil {
+		return false, err
+	}
+
+	if opts.store != nil {
+		if _, ok := opts.store.Lookup(u); ok {
+			return true, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u, nil)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = opts.credentials.Authorize(req, r.authConfig())
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := opts.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return true, nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return false, nil
+	}
+
+	return false, fmt.Errorf("unexpected status %s for %s", resp.Status, u)
+}
+
+// asset returns the name of the downloaded artifact
+func (r Fetch) asset(args tpl.Args) (string, error) {
+	if r.Type == "oci" && r.Layer != "" {
+		return args.Render(r.Layer)
+	}
+
+	rendered, err := args.Render(r.URL)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(rendered)
+	if err != nil {
+		return "", err
+	}
+
+	return path.Base(u.Path), nil
+}
+
+// getFile returns the content of a small remote file (e.g. checksums or
+// signatures)
+// When a store is available, the file is looked up there first, and saved
+// there once downloaded.
+func getFile(ctx context.Context, opts options, url string) ([]byte, error) {
+	if opts.store != nil {
+		defer opts.store.Lock(url)()
+
+		if file, ok := opts.store.Lookup(url); ok {
+			return os.ReadFile(file)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = opts.credentials.Authorize(req, opts.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := opts.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s for %s", resp.Status, url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.store != nil {
+		err = storeFile(opts.store, url, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return body, nil
+}
+
+// check runs verify on file, if set
+func check(verify func(string) error, file string) error {
+	if verify == nil {
+		return nil
+	}
+
+	return verify(file)
+}
+
+func storeFile(s *store.Store, url string, content []byte) error {
+	f, err := s.TempFile("file")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(content)
+	f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	_, err = s.Put(url, f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
 }
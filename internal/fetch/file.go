@@ -0,0 +1,95 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/mapping"
+	"github.com/devops-works/binenv/internal/platform"
+	"github.com/devops-works/binenv/internal/store"
+	"github.com/devops-works/binenv/internal/tpl"
+)
+
+// File handles releases available on the filesystem (e.g. a mounted share)
+//
+// The release is copied, so the original is never modified or removed.
+type File struct {
+	path   string
+	store  *store.Store
+	verify func(string) error
+}
+
+// Fetch copies the release and returns location of the copy
+func (f File) Fetch(ctx context.Context, dist, v string, mapper mapping.Mapper) (string, error) {
+	_, file, err := f.fetchFor(ctx, fmt.Sprintf("copying %s version %s", dist, v), v, mapper, platform.Current())
+	return file, err
+}
+
+func (f File) fetchFor(ctx context.Context, desc, v string, mapper mapping.Mapper, p platform.Platform) (string, string, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "File.Fetch").Logger()
+
+	args := tpl.NewFor(v, mapper, p)
+
+	key, err := args.Render(f.path)
+	if err != nil {
+		return "", "", err
+	}
+	src := strings.TrimPrefix(key, "file://")
+
+	if f.store != nil {
+		defer f.store.Lock(key)()
+
+		if file, ok := f.store.Lookup(key); ok {
+			err := check(f.verify, file)
+			if err == nil {
+				logger.Debug().Msgf("using cached artifact %s for %s", file, key)
+				return key, file, nil
+			}
+			logger.Debug().Err(err).Msgf("cached artifact %s for %s can not be used", file, key)
+		}
+	}
+
+	logger.Debug().Msgf("%s from %s", desc, src)
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", "", err
+	}
+	defer in.Close()
+
+	var dst *os.File
+	if f.store != nil {
+		dst, err = f.store.TempFile("file")
+	} else {
+		dst, err = os.CreateTemp("", "binenv")
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = io.Copy(dst, in)
+	dst.Close()
+	if err == nil {
+		err = check(f.verify, dst.Name())
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", "", err
+	}
+
+	if f.store == nil {
+		return key, dst.Name(), nil
+	}
+
+	file, err := f.store.Put(key, dst.Name())
+	if err != nil {
+		os.Remove(dst.Name())
+	}
+
+	return key, file, err
+}
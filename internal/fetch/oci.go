@@ -0,0 +1,181 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/mapping"
+	"github.com/devops-works/binenv/internal/oci"
+	"github.com/devops-works/binenv/internal/platform"
+	"github.com/devops-works/binenv/internal/store"
+	"github.com/devops-works/binenv/internal/tpl"
+)
+
+// OCI handles releases published as artifacts in an OCI registry (e.g. using
+// ORAS)
+//
+// When the reference points to an index, the manifest for the platform is
+// used. The layer is selected by its title annotation, unless the manifest
+// has a single layer.
+type OCI struct {
+	ref      string
+	layer    string
+	registry *oci.Client
+	store    *store.Store
+	transfer transfer
+	verify   func(string) error
+}
+
+// Fetch pulls the release layer and returns location of downloaded file
+func (o OCI) Fetch(ctx context.Context, dist, v string, mapper mapping.Mapper) (string, error) {
+	_, file, err := o.fetchFor(ctx, fmt.Sprintf("fetching %s version %s", dist, v), v, mapper, platform.Current())
+	return file, err
+}
+
+func (o OCI) fetchFor(ctx context.Context, desc, v string, mapper mapping.Mapper, p platform.Platform) (string, string, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "OCI.Fetch").Logger()
+
+	args := tpl.NewFor(v, mapper, p)
+
+	rendered, err := args.Render(o.ref)
+	if err != nil {
+		return "", "", err
+	}
+	ref, err := oci.ParseReference(rendered)
+	if err != nil {
+		return "", "", err
+	}
+
+	title := ""
+	if o.layer != "" {
+		title, err = args.Render(o.layer)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	// Tags can point to indexes, so the platform is part of the key
+	key := fmt.Sprintf("oci://%s?platform=%s", ref, p)
+	if title != "" {
+		key += "&layer=" + title
+	}
+
+	if o.store != nil {
+		defer o.store.Lock(key)()
+
+		if file, ok := o.store.Lookup(key); ok {
+			err := check(o.verify, file)
+			if err == nil {
+				logger.Debug().Msgf("using cached artifact %s for %s", file, key)
+				return key, file, nil
+			}
+			logger.Debug().Err(err).Msgf("cached artifact %s for %s can not be used", file, key)
+		}
+	}
+
+	logger.Debug().Msgf("resolving %s for %s", ref, p)
+
+	m, err := o.registry.Resolve(ctx, ref, p.OS, p.Arch)
+	if err != nil {
+		return "", "", err
+	}
+
+	layer, err := selectLayer(m.Files(), title)
+	if err != nil {
+		return "", "", fmt.Errorf("%w in %s", err, ref)
+	}
+
+	url := o.registry.BlobURL(ref, layer.Digest)
+
+	logger.Debug().Msgf("fetching layer %s (%s) at %s", layer.Digest, layer.Title(), url)
+
+	var dst *os.File
+	if o.store != nil {
+		dst, err = o.store.PartialFile(key)
+	} else {
+		dst, err = os.CreateTemp("", "binenv")
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	err = o.transfer.get(ctx, url, o.registry.Header(ref), dst, desc)
+	dst.Close()
+	if err != nil {
+		if o.store == nil || !resumable(ctx, err) {
+			removePartial(dst.Name())
+		}
+		return "", "", err
+	}
+
+	err = checkDigest(dst.Name(), layer.Digest)
+	if err == nil {
+		err = check(o.verify, dst.Name())
+	}
+	if err != nil {
+		removePartial(dst.Name())
+		return "", "", err
+	}
+
+	if o.store == nil {
+		return key, dst.Name(), nil
+	}
+
+	file, err := o.store.Put(key, dst.Name())
+	return key, file, err
+}
+
+// selectLayer returns the layer titled title, or the only layer if title is
+// empty
+func selectLayer(layers []oci.Descriptor, title string) (oci.Descriptor, error) {
+	if title == "" {
+		if len(layers) == 1 {
+			return layers[0], nil
+		}
+		titles := []string{}
+		for _, l := range layers {
+			titles = append(titles, l.Title())
+		}
+		return oci.Descriptor{}, fmt.Errorf("found %d layers (%s); set layer to select one", len(layers), strings.Join(titles, ", "))
+	}
+
+	for _, l := range layers {
+		if l.Title() == title {
+			return l, nil
+		}
+	}
+
+	return oci.Descriptor{}, fmt.Errorf("no layer titled %q", title)
+}
+
+// checkDigest verifies that file matches digest
+func checkDigest(file, digest string) error {
+	algo, want, _ := strings.Cut(digest, ":")
+	if algo != "sha256" {
+		return fmt.Errorf("unsupported digest %q", digest)
+	}
+
+	fd, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return err
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: got sha256:%s, expected %s", ErrChecksumMismatch, got, digest)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/devops-works/binenv/internal/oci"
+	"github.com/devops-works/binenv/internal/oci/ocitest"
+	"github.com/devops-works/binenv/internal/platform"
+	"github.com/devops-works/binenv/internal/store"
+)
+
+func TestOCI_fetchFor(t *testing.T) {
+	reg := ocitest.NewRegistry()
+	defer reg.Close()
+
+	reg.Push("org/single", "1.0.0", map[string][]byte{"tool.tgz": []byte("single layer")})
+	reg.Push("org/multi", "1.0.0", map[string][]byte{
+		"tool-linux-amd64.tgz":  []byte("linux amd64"),
+		"tool-darwin-arm64.tgz": []byte("darwin arm64"),
+	})
+	reg.PushIndex("org/index", "1.0.0", map[string]oci.Descriptor{
+		"linux/amd64":  reg.Push("org/index", "linux-amd64", map[string][]byte{"tool": []byte("index linux amd64")}),
+		"darwin/arm64": reg.Push("org/index", "darwin-arm64", map[string][]byte{"tool": []byte("index darwin arm64")}),
+	})
+
+	tests := []struct {
+		name     string
+		ref      string
+		layer    string
+		platform platform.Platform
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "single layer",
+			ref:      reg.URL + "/org/single:{{ .Version }}",
+			platform: platform.Platform{OS: "linux", Arch: "amd64"},
+			want:     "single layer",
+		},
+		{
+			name:     "layer by title",
+			ref:      reg.URL + "/org/multi:{{ .Version }}",
+			layer:    "tool-{{ .OS }}-{{ .Arch }}.tgz",
+			platform: platform.Platform{OS: "darwin", Arch: "arm64"},
+			want:     "darwin arm64",
+		},
+		{
+			name:     "ambiguous layer",
+			ref:      reg.URL + "/org/multi:{{ .Version }}",
+			platform: platform.Platform{OS: "linux", Arch: "amd64"},
+			wantErr:  true,
+		},
+		{
+			name:     "index",
+			ref:      reg.URL + "/org/index:{{ .Version }}",
+			platform: platform.Platform{OS: "linux", Arch: "amd64"},
+			want:     "index linux amd64",
+		},
+		{
+			name:     "platform missing from index",
+			ref:      reg.URL + "/org/index:{{ .Version }}",
+			platform: platform.Platform{OS: "windows", Arch: "amd64"},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown tag",
+			ref:      reg.URL + "/org/single:2.0.0",
+			platform: platform.Platform{OS: "linux", Arch: "amd64"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := store.New(t.TempDir(), 0750)
+
+			f, err := Fetch{Type: "oci", URL: tt.ref, Layer: tt.layer}.Factory(WithStore(s), WithClient(reg.Client()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			o := f.(OCI)
+			o.transfer = testTransfer(reg.Client())
+
+			key, file, err := o.fetchFor(context.Background(), "test", "1.0.0", nil, tt.platform)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OCI.fetchFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("OCI.fetchFor() fetched %q, want %q", got, tt.want)
+			}
+
+			if cached, ok := s.Lookup(key); !ok || cached != file {
+				t.Errorf("OCI.fetchFor() did not store %s", key)
+			}
+		})
+	}
+}
+
+func TestOCI_credentials(t *testing.T) {
+	reg := ocitest.NewRegistry()
+	defer reg.Close()
+
+	reg.Username, reg.Password = "binenv", "registry-token"
+	reg.Push("org/private", "1.0.0", map[string][]byte{"tool": []byte("private")})
+
+	fetch := func() error {
+		f, err := Fetch{Type: "oci", URL: reg.URL + "/org/private:1.0.0", TokenEnv: "BINENV_TEST_OCI_TOKEN"}.Factory(WithClient(reg.Client()))
+		if err != nil {
+			return err
+		}
+		file, err := f.Fetch(context.Background(), "private", "1.0.0", nil)
+		if err == nil {
+			os.Remove(file)
+		}
+		return err
+	}
+
+	t.Setenv("BINENV_TEST_OCI_TOKEN", "wrong")
+	if err := fetch(); err == nil {
+		t.Errorf("OCI.Fetch() succeeded with wrong credentials")
+	}
+
+	t.Setenv("BINENV_TEST_OCI_TOKEN", "registry-token")
+	if err := fetch(); err != nil {
+		t.Errorf("OCI.Fetch() error = %v", err)
+	}
+}
+
+func TestFile_fetchFor(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/tool-1.0.0-linux.tgz", []byte("from share"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := store.New(t.TempDir(), 0750)
+
+	for _, path := range []string{dir + "/tool-{{ .Version }}-{{ .OS }}.tgz", "file://" + dir + "/tool-{{ .Version }}-{{ .OS }}.tgz"} {
+		f, err := Fetch{Type: "file", URL: path}.Factory(WithStore(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, file, err := f.(File).fetchFor(context.Background(), "test", "1.0.0", nil, platform.Platform{OS: "linux", Arch: "amd64"})
+		if err != nil {
+			t.Fatalf("File.fetchFor() error = %v", err)
+		}
+		got, _ := os.ReadFile(file)
+		if string(got) != "from share" {
+			t.Errorf("File.fetchFor() copied %q", got)
+		}
+
+		// The original must be left untouched
+		if _, err := os.Stat(dir + "/tool-1.0.0-linux.tgz"); err != nil {
+			t.Errorf("File.fetchFor() removed the original file")
+		}
+	}
+
+	_, _, err := File{path: dir + "/missing"}.fetchFor(context.Background(), "test", "1.0.0", nil, platform.Current())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("File.fetchFor() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestFetch_Factory(t *testing.T) {
+	f, err := Fetch{Type: "ftp"}.Factory()
+	if f != nil || err == nil {
+		t.Errorf("Fetch.Factory() = %v, %v for unknown type, want an error", f, err)
+	}
+}
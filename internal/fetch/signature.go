@@ -0,0 +1,334 @@
+package fetch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/devops-works/binenv/internal/tpl"
+)
+
+// ErrBadSignature is returned when a downloaded file signature can not be
+// verified using the pinned public key
+var ErrBadSignature = errors.New("bad signature")
+
+// Signature contains detached signature configuration
+type Signature struct {
+	Type      string `yaml:"type"`       // one of minisign, cosign or gpg
+	URL       string `yaml:"url"`        // signature URL template
+	PublicKey string `yaml:"public_key"` // pinned public key
+}
+
+// IsZero returns true if no signature has been configured
+func (s Signature) IsZero() bool {
+	return s.Type == "" && s.URL == ""
+}
+
+// verify checks file against its detached signature using the pinned public
+// key
+//
+// Verification happens offline: only the signature itself is downloaded.
+func (s Signature) verify(ctx context.Context, opts options, file string, args tpl.Args) error {
+	logger := zerolog.Ctx(ctx).With().Str("func", "Signature.verify").Logger()
+
+	if s.PublicKey == "" {
+		return fmt.Errorf("no public key defined for %s signature", s.Type)
+	}
+
+	url, err := args.Render(s.URL)
+	if err != nil {
+		return err
+	}
+
+	logger.Debug().Msgf("fetching %s signature at %s", s.Type, url)
+
+	sig, err := getFile(ctx, opts, url)
+	if err != nil {
+		return fmt.Errorf("unable to download signature: %w", err)
+	}
+
+	switch s.Type {
+	case "minisign":
+		err = verifyMinisign(file, sig, s.PublicKey)
+	case "cosign":
+		err = verifyCosign(file, sig, s.PublicKey)
+	case "gpg":
+		err = verifyGPG(file, sig, s.PublicKey)
+	default:
+		return fmt.Errorf("unsupported signature type %q", s.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("%s signature verification failed for %s: %w", s.Type, url, err)
+	}
+
+	logger.Debug().Msgf("%s signature verified using %s", s.Type, url)
+
+	return nil
+}
+
+// verifyMinisign checks a minisign signature
+// See https://jedisct1.github.io/minisign/ for formats
+func verifyMinisign(file string, sig []byte, key string) error {
+	pk, err := decodeMinisign(key, 42)
+	if err != nil {
+		return fmt.Errorf("invalid minisign public key: %w", err)
+	}
+
+	lines := nonCommentLines(sig, "untrusted comment:")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "trusted comment: ") {
+		return fmt.Errorf("invalid minisign signature file")
+	}
+
+	s, err := decodeMinisign(lines[0], 74)
+	if err != nil {
+		return fmt.Errorf("invalid minisign signature: %w", err)
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+
+	if !bytes.Equal(pk[2:10], s[2:10]) {
+		return fmt.Errorf("%w: key id mismatch", ErrBadSignature)
+	}
+
+	pub := ed25519.PublicKey(pk[10:])
+
+	var msg []byte
+	switch string(s[:2]) {
+	case "Ed":
+		msg, err = os.ReadFile(file)
+	case "ED":
+		msg, err = blake2bFile(file)
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", s[:2])
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(pub, msg, s[10:]) {
+		return ErrBadSignature
+	}
+
+	// The global signature covers the signature and the trusted comment
+	trusted := strings.TrimPrefix(lines[1], "trusted comment: ")
+	signed := append([]byte{}, s[10:]...)
+	signed = append(signed, trusted...)
+	if !ed25519.Verify(pub, signed, global) {
+		return fmt.Errorf("%w: invalid trusted comment signature", ErrBadSignature)
+	}
+
+	return nil
+}
+
+// verifyCosign checks a cosign signature
+// sig can either be a raw base64 signature (cosign sign-blob), a cosign bundle
+// (cosign sign-blob --bundle) or a Sigstore bundle
+func verifyCosign(file string, sig []byte, key string) error {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return fmt.Errorf("invalid cosign public key: no PEM data found")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid cosign public key: %w", err)
+	}
+
+	raw, digest, err := parseCosignSignature(sig)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, fd); err != nil {
+		return err
+	}
+	sum := h.Sum(nil)
+
+	if digest != nil && !bytes.Equal(digest, sum) {
+		return fmt.Errorf("%w: bundle digest does not match file", ErrBadSignature)
+	}
+
+	ok := false
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(k, sum, raw)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, sum, raw) == nil
+	case ed25519.PublicKey:
+		msg, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		ok = ed25519.Verify(k, msg, raw)
+	default:
+		return fmt.Errorf("unsupported cosign public key type %T", pub)
+	}
+
+	if !ok {
+		return ErrBadSignature
+	}
+
+	return nil
+}
+
+// parseCosignSignature returns the raw signature and, when available, the
+// signed digest
+func parseCosignSignature(sig []byte) ([]byte, []byte, error) {
+	sig = bytes.TrimSpace(sig)
+
+	if len(sig) == 0 || sig[0] != '{' {
+		raw, err := base64.StdEncoding.DecodeString(string(sig))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid cosign signature: %w", err)
+		}
+		return raw, nil, nil
+	}
+
+	bundle := struct {
+		// cosign bundle
+		Base64Signature string `json:"base64Signature"`
+		// Sigstore bundle
+		MessageSignature struct {
+			MessageDigest struct {
+				Algorithm string `json:"algorithm"`
+				Digest    string `json:"digest"`
+			} `json:"messageDigest"`
+			Signature string `json:"signature"`
+		} `json:"messageSignature"`
+	}{}
+
+	if err := json.Unmarshal(sig, &bundle); err != nil {
+		return nil, nil, fmt.Errorf("invalid cosign bundle: %w", err)
+	}
+
+	if bundle.Base64Signature != "" {
+		raw, err := base64.StdEncoding.DecodeString(bundle.Base64Signature)
+		return raw, nil, err
+	}
+
+	ms := bundle.MessageSignature
+	if ms.Signature == "" {
+		return nil, nil, fmt.Errorf("invalid cosign bundle: no signature found")
+	}
+	raw, err := base64.StdEncoding.DecodeString(ms.Signature)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cosign bundle: %w", err)
+	}
+
+	var digest []byte
+	if ms.MessageDigest.Digest != "" {
+		if ms.MessageDigest.Algorithm != "SHA2_256" {
+			return nil, nil, fmt.Errorf("unsupported bundle digest algorithm %q", ms.MessageDigest.Algorithm)
+		}
+		digest, err = base64.StdEncoding.DecodeString(ms.MessageDigest.Digest)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid cosign bundle digest: %w", err)
+		}
+	}
+
+	return raw, digest, nil
+}
+
+// verifyGPG checks an OpenPGP detached signature, armored or not
+func verifyGPG(file string, sig []byte, key string) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	if err != nil {
+		return fmt.Errorf("invalid OpenPGP public key: %w", err)
+	}
+
+	fd, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN")) {
+		_, err = openpgp.CheckArmoredDetachedSignature(keyring, fd, bytes.NewReader(sig), nil)
+	} else {
+		_, err = openpgp.CheckDetachedSignature(keyring, fd, bytes.NewReader(sig), nil)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+
+	return nil
+}
+
+// decodeMinisign decodes a base64 minisign key or signature, optionally
+// preceded by an untrusted comment
+func decodeMinisign(s string, size int) ([]byte, error) {
+	lines := nonCommentLines([]byte(s), "untrusted comment:")
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no data found")
+	}
+
+	b, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("unexpected length %d", len(b))
+	}
+
+	return b, nil
+}
+
+// nonCommentLines returns trimmed non-empty lines not starting with comment
+func nonCommentLines(content []byte, comment string) []string {
+	lines := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, comment) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func blake2bFile(file string) ([]byte, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(h, fd); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,181 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func writeTestFile(t *testing.T, content []byte) string {
+	t.Helper()
+
+	f := filepath.Join(t.TempDir(), "artifact")
+	if err := os.WriteFile(f, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func Test_verifyMinisign(t *testing.T) {
+	content := []byte("some binary content")
+	file := writeTestFile(t, content)
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte("01234567")
+
+	pk := append([]byte("Ed"), keyID...)
+	pk = append(pk, pub...)
+	key := "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(pk)
+
+	sign := func(msg []byte, alg, trusted string) []byte {
+		s := ed25519.Sign(priv, msg)
+		raw := append([]byte(alg), keyID...)
+		raw = append(raw, s...)
+		global := ed25519.Sign(priv, append(append([]byte{}, s...), trusted...))
+		return []byte(fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+			base64.StdEncoding.EncodeToString(raw),
+			trusted,
+			base64.StdEncoding.EncodeToString(global),
+		))
+	}
+
+	hashed, err := blake2bFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		sig     []byte
+		wantErr bool
+	}{
+		{name: "legacy", sig: sign(content, "Ed", "timestamp:1")},
+		{name: "prehashed", sig: sign(hashed, "ED", "timestamp:1")},
+		{name: "tampered", sig: sign([]byte("other content"), "Ed", "timestamp:1"), wantErr: true},
+		{name: "tampered trusted comment", sig: bytes.Replace(sign(content, "Ed", "timestamp:1"), []byte("timestamp:1"), []byte("timestamp:2"), 1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyMinisign(file, tt.sig, key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyMinisign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_verifyCosign(t *testing.T) {
+	content := []byte("some binary content")
+	file := writeTestFile(t, content)
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	sum := sha256.Sum256(content)
+	raw, err := ecdsa.SignASN1(rand.Reader, priv, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.StdEncoding.EncodeToString(raw)
+	digest := base64.StdEncoding.EncodeToString(sum[:])
+	other := sha256.Sum256([]byte("other content"))
+
+	tests := []struct {
+		name    string
+		sig     string
+		wantErr bool
+	}{
+		{name: "raw", sig: b64},
+		{name: "cosign bundle", sig: `{"base64Signature":"` + b64 + `"}`},
+		{name: "sigstore bundle", sig: `{"messageSignature":{"messageDigest":{"algorithm":"SHA2_256","digest":"` + digest + `"},"signature":"` + b64 + `"}}`},
+		{name: "digest mismatch", sig: `{"messageSignature":{"messageDigest":{"algorithm":"SHA2_256","digest":"` + base64.StdEncoding.EncodeToString(other[:]) + `"},"signature":"` + b64 + `"}}`, wantErr: true},
+		{name: "bad signature", sig: base64.StdEncoding.EncodeToString([]byte("nope")), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyCosign(file, []byte(tt.sig), key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyCosign() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_verifyGPG(t *testing.T) {
+	content := []byte("some binary content")
+	file := writeTestFile(t, content)
+
+	entity, err := openpgp.NewEntity("binenv", "test", "binenv@example.org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := bytes.Buffer{}
+	w, err := armor.Encode(&key, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	armored := bytes.Buffer{}
+	if err := openpgp.ArmoredDetachSign(&armored, entity, bytes.NewReader(content), nil); err != nil {
+		t.Fatal(err)
+	}
+	binary := bytes.Buffer{}
+	if err := openpgp.DetachSign(&binary, entity, bytes.NewReader(content), nil); err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Buffer{}
+	if err := openpgp.DetachSign(&tampered, entity, bytes.NewReader([]byte("other content")), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		sig     []byte
+		wantErr error
+	}{
+		{name: "armored", sig: armored.Bytes()},
+		{name: "binary", sig: binary.Bytes()},
+		{name: "tampered", sig: tampered.Bytes(), wantErr: ErrBadSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyGPG(file, tt.sig, key.String())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("verifyGPG() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
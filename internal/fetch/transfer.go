@@ -0,0 +1,337 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/progress"
+)
+
+var (
+	// ErrIncompleteTransfer is returned when the amount of data received does
+	// not match what the server announced
+	ErrIncompleteTransfer = errors.New("incomplete transfer")
+
+	// ErrNotFound is returned when the release does not exist at the
+	// rendered URL
+	ErrNotFound = errors.New("release not found")
+)
+
+// statusError is returned for unexpected HTTP statuses
+type statusError struct {
+	url  string
+	code int
+	text string
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("unable to download %s: %s", e.url, e.text)
+}
+
+// Is makes not found statuses match ErrNotFound
+func (e statusError) Is(target error) bool {
+	return target == ErrNotFound && (e.code == http.StatusNotFound || e.code == http.StatusGone)
+}
+
+// transfer downloads files, resuming partial transfers using Range requests
+// and retrying transient failures with exponential backoff
+type transfer struct {
+	client     *http.Client
+	attempts   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+func newTransfer(client *http.Client) transfer {
+	return transfer{
+		client:     client,
+		attempts:   5,
+		backoff:    500 * time.Millisecond,
+		maxBackoff: 15 * time.Second,
+	}
+}
+
+// get downloads url into dst
+//
+// If dst is not empty, the transfer is resumed from its current size, provided
+// the validator saved by a previous failed transfer is available; otherwise
+// the transfer starts over since dst could be part of another file.
+func (t transfer) get(ctx context.Context, url string, headers map[string]string, dst *os.File, desc string) (err error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "transfer.get").Logger()
+
+	st, err := dst.Stat()
+	if err != nil {
+		return err
+	}
+
+	state := transferState{
+		offset: st.Size(),
+		total:  -1,
+	}
+
+	if state.offset > 0 {
+		state.validator = readValidator(dst.Name())
+		if state.validator == "" {
+			logger.Debug().Msgf("partial transfer of %s can not be validated; starting over", url)
+			if err := dst.Truncate(0); err != nil {
+				return err
+			}
+			state.offset = 0
+		}
+	}
+
+	defer func() {
+		saveValidator(dst.Name(), state, err)
+	}()
+
+	for attempt := 1; ; attempt++ {
+		err = t.try(ctx, url, headers, dst, desc, &state)
+		if err == nil {
+			if state.tracker != nil {
+				state.tracker.Finish()
+			}
+			return nil
+		}
+
+		if !retryable(err) || attempt >= t.attempts {
+			return err
+		}
+
+		delay := t.delay(attempt)
+		logger.Warn().Err(err).Msgf("transfer of %s failed (attempt %d/%d); retrying in %s", url, attempt, t.attempts, delay.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// validatorFile returns the file holding the validator of partial download
+// file
+func validatorFile(file string) string {
+	return file + ".validator"
+}
+
+// readValidator returns the validator saved for partial download file, if any
+func readValidator(file string) string {
+	v, err := os.ReadFile(validatorFile(file))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(v))
+}
+
+// saveValidator saves the validator for partial download file when the
+// transfer failed, so it can be resumed later, and removes it otherwise
+func saveValidator(file string, state transferState, err error) {
+	if err == nil || state.offset == 0 || state.validator == "" {
+		os.Remove(validatorFile(file))
+		return
+	}
+
+	os.WriteFile(validatorFile(file), []byte(state.validator), 0600)
+}
+
+// resumable tells whether a partial download that failed with err can be
+// resumed later: transient errors and interruptions are
+func resumable(ctx context.Context, err error) bool {
+	return retryable(err) || ctx.Err() != nil
+}
+
+// removePartial removes partial download file and its validator
+func removePartial(file string) {
+	os.Remove(file)
+	os.Remove(validatorFile(file))
+}
+
+// transferState holds state shared between attempts
+type transferState struct {
+	offset    int64
+	total     int64
+	validator string
+	tracker   progress.Tracker
+}
+
+func (t transfer) try(ctx context.Context, url string, headers map[string]string, dst *os.File, desc string, state *transferState) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range headers {
+		req.Header.Add(k, v)
+	}
+
+	if state.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", state.offset))
+		// Make sure we get the remaining part of the same file
+		if state.validator != "" {
+			req.Header.Set("If-Range", state.validator)
+		}
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Range not honored (or resource changed): start over
+		if state.offset > 0 {
+			if err := dst.Truncate(0); err != nil {
+				return err
+			}
+			state.offset = 0
+		}
+		state.total = resp.ContentLength
+	case http.StatusPartialContent:
+		start, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != state.offset {
+			// We can not trust what we have; restart from scratch
+			if err := dst.Truncate(0); err != nil {
+				return err
+			}
+			state.offset = 0
+			return fmt.Errorf("%w: unexpected Content-Range %q", ErrIncompleteTransfer, resp.Header.Get("Content-Range"))
+		}
+		state.total = total
+	case http.StatusRequestedRangeNotSatisfiable:
+		_, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err == nil && total == state.offset {
+			// We already have everything
+			state.total = total
+			return nil
+		}
+		if err := dst.Truncate(0); err != nil {
+			return err
+		}
+		state.offset = 0
+		return fmt.Errorf("%w: unable to resume transfer", ErrIncompleteTransfer)
+	default:
+		return statusError{url: url, code: resp.StatusCode, text: resp.Status}
+	}
+
+	if v := resp.Header.Get("ETag"); v != "" && !strings.HasPrefix(v, "W/") {
+		state.validator = v
+	} else if v := resp.Header.Get("Last-Modified"); v != "" {
+		state.validator = v
+	}
+
+	if state.tracker == nil {
+		state.tracker = progress.FromContext(ctx).Track(desc, state.total)
+	}
+	state.tracker.SetTotal(state.total)
+	state.tracker.SetCurrent(state.offset)
+
+	if _, err := dst.Seek(state.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	n, err := io.Copy(io.MultiWriter(dst, state.tracker), resp.Body)
+	state.offset += n
+	if err != nil {
+		return err
+	}
+
+	if state.total >= 0 && state.offset != state.total {
+		return fmt.Errorf("%w: got %d bytes, expected %d", ErrIncompleteTransfer, state.offset, state.total)
+	}
+
+	return nil
+}
+
+// delay returns the backoff delay for attempt, with jitter
+func (t transfer) delay(attempt int) time.Duration {
+	d := t.backoff << (attempt - 1)
+	if d > t.maxBackoff || d <= 0 {
+		d = t.maxBackoff
+	}
+
+	if d > 1 {
+		d += time.Duration(rand.Int63n(int64(d) / 2))
+	}
+
+	return d
+}
+
+// retryable returns true if err is likely transient
+// Other errors (e.g. certificate or DNS resolution errors) would not go away
+// by retrying.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var se statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 ||
+			se.code == http.StatusTooManyRequests ||
+			se.code == http.StatusRequestTimeout
+	}
+
+	// Truncated bodies, dropped connections, ...
+	if errors.Is(err, ErrIncompleteTransfer) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// parseContentRange parses `bytes start-end/total` and `bytes */total`
+// headers; total is -1 when unknown
+func parseContentRange(h string) (int64, int64, error) {
+	spec, ok := strings.CutPrefix(h, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", h)
+	}
+
+	rng, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", h)
+	}
+
+	total := int64(-1)
+	if size != "*" {
+		v, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", h, err)
+		}
+		total = v
+	}
+
+	if rng == "*" {
+		return 0, total, nil
+	}
+
+	first, _, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", h)
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", h, err)
+	}
+
+	return start, total, nil
+}
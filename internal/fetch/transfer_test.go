@@ -0,0 +1,277 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func testTransfer(client *http.Client) transfer {
+	return transfer{
+		client:     client,
+		attempts:   4,
+		backoff:    time.Millisecond,
+		maxBackoff: 5 * time.Millisecond,
+	}
+}
+
+func TestTransfer_get(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	modtime := time.Now()
+
+	tests := []struct {
+		name string
+		// failure returns true when it has dealt with the request
+		failure     func(w http.ResponseWriter, r *http.Request, count int32) bool
+		partial     int
+		validator   string
+		wantErr     bool
+		wantQueries int32
+		// wantValidator is the validator saved for failed transfers
+		wantValidator string
+	}{
+		{
+			name:        "straight",
+			failure:     func(w http.ResponseWriter, r *http.Request, count int32) bool { return false },
+			wantQueries: 1,
+		},
+		{
+			name: "connection dropped",
+			failure: func(w http.ResponseWriter, r *http.Request, count int32) bool {
+				if count > 2 {
+					return false
+				}
+				// Announce full length, send part of the content and hang up
+				conn, buf, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.Close()
+				fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n", len(content))
+				buf.Write(content[:len(content)/3])
+				buf.Flush()
+				return true
+			},
+			wantQueries: 3,
+		},
+		{
+			name: "server errors",
+			failure: func(w http.ResponseWriter, r *http.Request, count int32) bool {
+				if count > 2 {
+					return false
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return true
+			},
+			wantQueries: 3,
+		},
+		{
+			name: "not found",
+			failure: func(w http.ResponseWriter, r *http.Request, count int32) bool {
+				w.WriteHeader(http.StatusNotFound)
+				return true
+			},
+			wantErr:     true,
+			wantQueries: 1,
+		},
+		{
+			name: "too many failures",
+			failure: func(w http.ResponseWriter, r *http.Request, count int32) bool {
+				w.WriteHeader(http.StatusBadGateway)
+				return true
+			},
+			wantErr:     true,
+			wantQueries: 4,
+		},
+		{
+			name: "resume existing partial file",
+			failure: func(w http.ResponseWriter, r *http.Request, count int32) bool {
+				if r.Header.Get("Range") != "bytes=1000-" {
+					t.Errorf("unexpected Range header %q", r.Header.Get("Range"))
+				}
+				return false
+			},
+			partial:     1000,
+			validator:   modtime.UTC().Format(http.TimeFormat),
+			wantQueries: 1,
+		},
+		{
+			name: "partial file without validator",
+			failure: func(w http.ResponseWriter, r *http.Request, count int32) bool {
+				if r.Header.Get("Range") != "" {
+					t.Errorf("unexpected Range header %q", r.Header.Get("Range"))
+				}
+				return false
+			},
+			partial:     1000,
+			wantQueries: 1,
+		},
+		{
+			name:        "partial file of a changed file",
+			failure:     func(w http.ResponseWriter, r *http.Request, count int32) bool { return false },
+			partial:     1000,
+			validator:   modtime.Add(-time.Hour).UTC().Format(http.TimeFormat),
+			wantQueries: 1,
+		},
+		{
+			name: "validator saved on failure",
+			failure: func(w http.ResponseWriter, r *http.Request, count int32) bool {
+				// Always hang up, so the transfer fails
+				conn, buf, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.Close()
+				fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nETag: \"v1\"\r\nContent-Length: %d\r\n\r\n", len(content))
+				buf.Write(content[:100])
+				buf.Flush()
+				return true
+			},
+			wantErr:       true,
+			wantQueries:   4,
+			wantValidator: `"v1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var count int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c := atomic.AddInt32(&count, 1)
+				if tt.failure(w, r, c) {
+					return
+				}
+				http.ServeContent(w, r, "artifact", modtime, bytes.NewReader(content))
+			}))
+			defer ts.Close()
+
+			dst, err := os.CreateTemp(t.TempDir(), "transfer")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dst.Close()
+			dst.Write(content[:tt.partial])
+			if tt.validator != "" {
+				os.WriteFile(validatorFile(dst.Name()), []byte(tt.validator), 0600)
+			}
+
+			err = testTransfer(ts.Client()).get(context.Background(), ts.URL, nil, dst, "test")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("transfer.get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if count != tt.wantQueries {
+				t.Errorf("transfer.get() made %d queries, want %d", count, tt.wantQueries)
+			}
+			if tt.wantErr {
+				if got := readValidator(dst.Name()); got != tt.wantValidator {
+					t.Errorf("transfer.get() saved validator %q, want %q", got, tt.wantValidator)
+				}
+				return
+			}
+			if _, err := os.Stat(validatorFile(dst.Name())); err == nil {
+				t.Errorf("transfer.get() kept validator of complete transfer")
+			}
+
+			got, err := os.ReadFile(dst.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("transfer.get() wrote %d bytes, want %d identical bytes", len(got), len(content))
+			}
+		})
+	}
+}
+
+func TestTransfer_getIncomplete(t *testing.T) {
+	// Server never sends the whole content, and does not support ranges
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\n%s", strings.Repeat("a", 50))
+		buf.Flush()
+	}))
+	defer ts.Close()
+
+	dst, err := os.CreateTemp(t.TempDir(), "transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	err = testTransfer(ts.Client()).get(context.Background(), ts.URL, nil, dst, "test")
+	if err == nil {
+		t.Fatalf("transfer.get() succeeded with truncated content")
+	}
+}
+
+func Test_retryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server error", err: statusError{code: http.StatusBadGateway}, want: true},
+		{name: "too many requests", err: statusError{code: http.StatusTooManyRequests}, want: true},
+		{name: "not found", err: statusError{code: http.StatusNotFound}, want: false},
+		{name: "incomplete", err: fmt.Errorf("%w: got 1 bytes, expected 2", ErrIncompleteTransfer), want: true},
+		{name: "truncated body", err: io.ErrUnexpectedEOF, want: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, want: true},
+		{name: "connection refused", err: &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, want: true},
+		{name: "timeout", err: &url.Error{Op: "Get", Err: &net.DNSError{IsTimeout: true}}, want: true},
+		{name: "unknown host", err: &url.Error{Op: "Get", Err: &net.DNSError{IsNotFound: true}}, want: false},
+		{name: "bad certificate", err: &url.Error{Op: "Get", Err: x509.UnknownAuthorityError{}}, want: false},
+		{name: "canceled", err: &url.Error{Op: "Get", Err: context.Canceled}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err); got != tt.want {
+				t.Errorf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseContentRange(t *testing.T) {
+	tests := []struct {
+		header    string
+		wantStart int64
+		wantTotal int64
+		wantErr   bool
+	}{
+		{header: "bytes 100-199/200", wantStart: 100, wantTotal: 200},
+		{header: "bytes 100-199/*", wantStart: 100, wantTotal: -1},
+		{header: "bytes */200", wantStart: 0, wantTotal: 200},
+		{header: "items 1-2/3", wantErr: true},
+		{header: "bytes 100-199", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			start, total, err := parseContentRange(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseContentRange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if start != tt.wantStart || total != tt.wantTotal {
+				t.Errorf("parseContentRange() = %d, %d, want %d, %d", start, total, tt.wantStart, tt.wantTotal)
+			}
+		})
+	}
+}
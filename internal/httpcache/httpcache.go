@@ -0,0 +1,164 @@
+// Package httpcache persists HTTP validators so listers can send
+// conditional requests
+package httpcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache maps URLs to the validators (ETag, Last-Modified) received with
+// them, and to what the caller extracted from the response so it can be
+// reused when the server answers 304 Not Modified
+//
+// A nil Cache is valid and disables conditional requests.
+type Cache struct {
+	file string
+	mode os.FileMode
+
+	mu      sync.Mutex
+	entries map[string]Entry
+	dirty   bool
+}
+
+// Entry is a cached response
+type Entry struct {
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	Data         json.RawMessage `json:"data"`
+}
+
+// Open loads the cache stored in file
+// A missing file returns an empty cache. mode is used when saving.
+func Open(file string, mode os.FileMode) (*Cache, error) {
+	c := &Cache{
+		file:    file,
+		mode:    mode,
+		entries: make(map[string]Entry),
+	}
+
+	js, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if err := json.Unmarshal(js, &c.entries); err != nil {
+		// Start over: the worst case is a full fetch
+		c.entries = make(map[string]Entry)
+		return c, fmt.Errorf("unable to unmarshal %s: %w", file, err)
+	}
+
+	return c, nil
+}
+
+// Get returns the entry for url
+func (c *Cache) Get(url string) (Entry, bool) {
+	if c == nil {
+		return Entry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[url]
+	return e, ok
+}
+
+// Prepare adds conditional headers to req when an entry exists for its URL,
+// and returns this entry
+func (c *Cache) Prepare(req *http.Request) (Entry, bool) {
+	e, ok := c.Get(req.URL.String())
+	if !ok {
+		return e, false
+	}
+
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+
+	return e, true
+}
+
+// Set stores data for the URL of resp, along with its validators
+// Nothing is stored when the response has no validators.
+func (c *Cache) Set(resp *http.Response, data interface{}) error {
+	if c == nil {
+		return nil
+	}
+
+	e := Entry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if e.ETag == "" && e.LastModified == "" {
+		return nil
+	}
+
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	e.Data = js
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[resp.Request.URL.String()] = e
+	c.dirty = true
+
+	return nil
+}
+
+// Save writes the cache to disk if it has been modified
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	js, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+
+	// Write atomically so an interrupted update does not leave a truncated
+	// cache
+	tmp, err := os.CreateTemp(filepath.Dir(c.file), ".etags-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(js); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), c.mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.file); err != nil {
+		return err
+	}
+
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,93 @@
+package httpcache
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache_SetPrepareSave(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "etags.json")
+
+	c, err := Open(file, 0640)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.org/releases?page=1", nil)
+	if _, ok := c.Prepare(req); ok {
+		t.Errorf("Cache.Prepare() found an entry in an empty cache")
+	}
+
+	// No validators, nothing to remember
+	resp := &http.Response{Request: req, Header: http.Header{}}
+	c.Set(resp, []string{"1.0.0"})
+	if _, ok := c.Get(req.URL.String()); ok {
+		t.Errorf("Cache.Set() stored a response without validators")
+	}
+
+	resp.Header.Set("ETag", `W/"abc"`)
+	resp.Header.Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+	if err := c.Set(resp, []string{"1.0.0"}); err != nil {
+		t.Fatalf("Cache.Set() error = %v", err)
+	}
+
+	if err := c.Save(); err != nil {
+		t.Fatalf("Cache.Save() error = %v", err)
+	}
+	if fi, err := os.Stat(file); err != nil || fi.Mode().Perm() != 0640 {
+		t.Errorf("Cache.Save() wrote %v (%v), want mode 0640", fi, err)
+	}
+
+	c, err = Open(file, 0640)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://example.org/releases?page=1", nil)
+	e, ok := c.Prepare(req)
+	if !ok {
+		t.Fatalf("Cache.Prepare() did not find saved entry")
+	}
+	if got := req.Header.Get("If-None-Match"); got != `W/"abc"` {
+		t.Errorf("If-None-Match = %q, want %q", got, `W/"abc"`)
+	}
+	if got := req.Header.Get("If-Modified-Since"); got != "Mon, 02 Jan 2006 15:04:05 GMT" {
+		t.Errorf("If-Modified-Since = %q", got)
+	}
+	if string(e.Data) != `["1.0.0"]` {
+		t.Errorf("Entry.Data = %s, want %s", e.Data, `["1.0.0"]`)
+	}
+}
+
+func TestCache_nil(t *testing.T) {
+	var c *Cache
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.org/", nil)
+	if _, ok := c.Prepare(req); ok {
+		t.Errorf("nil Cache.Prepare() found an entry")
+	}
+	if err := c.Set(&http.Response{Request: req, Header: http.Header{"Etag": {"x"}}}, nil); err != nil {
+		t.Errorf("nil Cache.Set() error = %v", err)
+	}
+	if err := c.Save(); err != nil {
+		t.Errorf("nil Cache.Save() error = %v", err)
+	}
+}
+
+func TestOpen_invalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "etags.json")
+	os.WriteFile(file, []byte("{"), 0640)
+
+	c, err := Open(file, 0640)
+	if err == nil {
+		t.Errorf("Open() succeeded with an invalid file")
+	}
+	if c == nil {
+		t.Fatalf("Open() returned no cache")
+	}
+	if _, ok := c.Get("anything"); ok {
+		t.Errorf("Cache.Get() found an entry in a reset cache")
+	}
+}
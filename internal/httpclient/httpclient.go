@@ -0,0 +1,205 @@
+// Package httpclient builds the HTTP client shared by all binenv components
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultTimeout is used when no timeout is configured
+const DefaultTimeout = 30 * time.Second
+
+// Config holds HTTP client configuration
+type Config struct {
+	// Proxy URL used for all requests; when empty, HTTP_PROXY, HTTPS_PROXY
+	// and NO_PROXY environment variables are used
+	Proxy string `yaml:"proxy"`
+	// NoProxy is a comma separated list of hosts or domains that must not
+	// use Proxy
+	NoProxy string `yaml:"no_proxy"`
+	// CABundle is a PEM file containing CA certificates trusted in addition
+	// to system ones
+	CABundle string `yaml:"ca_bundle"`
+	// ClientCert and ClientKey are PEM files used for TLS client
+	// authentication
+	ClientCert string `yaml:"client_cert"`
+	ClientKey  string `yaml:"client_key"`
+	// Timeout is the maximum time to wait for a connection and response
+	// headers; it does not limit the time spent downloading bodies
+	Timeout time.Duration `yaml:"timeout"`
+	// Trace logs requests, responses and connection details
+	Trace bool `yaml:"trace"`
+}
+
+// Merge returns c with fields set in o overriding those in c
+func (c Config) Merge(o Config) Config {
+	if o.Proxy != "" {
+		c.Proxy = o.Proxy
+	}
+	if o.NoProxy != "" {
+		c.NoProxy = o.NoProxy
+	}
+	if o.CABundle != "" {
+		c.CABundle = o.CABundle
+	}
+	if o.ClientCert != "" {
+		c.ClientCert = o.ClientCert
+	}
+	if o.ClientKey != "" {
+		c.ClientKey = o.ClientKey
+	}
+	if o.Timeout != 0 {
+		c.Timeout = o.Timeout
+	}
+	if o.Trace {
+		c.Trace = true
+	}
+
+	return c
+}
+
+// Transport returns a transport configured according to c
+func (c Config) Transport() (*http.Transport, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	proxy, err := c.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}, nil
+}
+
+func (c Config) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if c.Proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxy, err := url.Parse(c.Proxy)
+	if err != nil || proxy.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", c.Proxy)
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if noProxy(c.NoProxy, req.URL.Hostname()) {
+			return nil, nil
+		}
+		return proxy, nil
+	}, nil
+}
+
+// noProxy returns true if host matches an entry in list
+// Entries match the host itself and, for domains, its subdomains.
+func noProxy(list, host string) bool {
+	host = strings.ToLower(host)
+
+	for _, e := range strings.Split(list, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		if e == "*" {
+			return true
+		}
+		if h, _, err := net.SplitHostPort(e); err == nil {
+			e = h
+		}
+		e = strings.TrimPrefix(strings.TrimPrefix(e, "*"), ".")
+		if host == e || strings.HasSuffix(host, "."+e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c Config) tlsConfig() (*tls.Config, error) {
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if c.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in CA bundle %s", c.CABundle)
+		}
+
+		conf.RootCAs = pool
+	}
+
+	switch {
+	case c.ClientCert != "" && c.ClientKey != "":
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	case c.ClientCert != "" || c.ClientKey != "":
+		return nil, fmt.Errorf("both client certificate and key are required")
+	}
+
+	return conf, nil
+}
+
+// New returns a client using c configuration
+// userAgent is sent with every request; wrap can be used to add transport
+// layers (e.g. URL rewriting) on top of the tracing transport.
+func New(c Config, userAgent string, logger zerolog.Logger, wrap ...func(http.RoundTripper) http.RoundTripper) (*http.Client, error) {
+	t, err := c.Transport()
+	if err != nil {
+		return nil, err
+	}
+
+	var rt http.RoundTripper = Transport{
+		Base:      t,
+		UserAgent: userAgent,
+		Trace:     c.Trace,
+		Logger:    logger,
+	}
+
+	for _, w := range wrap {
+		rt = w(rt)
+	}
+
+	return &http.Client{
+		Transport: rt,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func writePEM(t *testing.T, typ string, der []byte) string {
+	t.Helper()
+
+	f := filepath.Join(t.TempDir(), "file.pem")
+	if err := os.WriteFile(f, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestNew(t *testing.T) {
+	var gotUA string
+	var gotCert bool
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+		gotCert = len(r.TLS.PeerCertificates) > 0
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+
+	ca := writePEM(t, "CERTIFICATE", ts.Certificate().Raw)
+
+	// Client certificate
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "binenv"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := writePEM(t, "CERTIFICATE", der)
+	certKey := writePEM(t, "EC PRIVATE KEY", keyDER)
+
+	tests := []struct {
+		name     string
+		config   Config
+		wantErr  bool
+		wantCert bool
+	}{
+		{name: "unknown CA", config: Config{}, wantErr: true},
+		{name: "CA bundle", config: Config{CABundle: ca}},
+		{name: "client certificate", config: Config{CABundle: ca, ClientCert: cert, ClientKey: certKey}, wantCert: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUA, gotCert = "", false
+
+			c, err := New(tt.config, "binenv/test", zerolog.Nop())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := c.Get(ts.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+
+			if gotUA != "binenv/test" {
+				t.Errorf("got User-Agent %q, want %q", gotUA, "binenv/test")
+			}
+			if gotCert != tt.wantCert {
+				t.Errorf("got client certificate %t, want %t", gotCert, tt.wantCert)
+			}
+		})
+	}
+}
+
+func TestConfig_Transport(t *testing.T) {
+	if _, err := (Config{ClientCert: "cert.pem"}).Transport(); err == nil {
+		t.Errorf("Transport() accepted a client certificate without key")
+	}
+	if _, err := (Config{Proxy: "not a url"}).Transport(); err == nil {
+		t.Errorf("Transport() accepted an invalid proxy")
+	}
+
+	tr, err := (Config{Proxy: "http://proxy:3128", NoProxy: "internal.example.org"}).Transport()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for host, want := range map[string]string{
+		"https://github.com/":                   "http://proxy:3128",
+		"https://internal.example.org/":         "",
+		"https://nexus.internal.example.org/":   "",
+		"https://notinternal.example.org:8443/": "http://proxy:3128",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, host, nil)
+		u, err := tr.Proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if u != nil {
+			got = u.String()
+		}
+		if got != want {
+			t.Errorf("proxy for %s = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func Test_noProxy(t *testing.T) {
+	tests := []struct {
+		list string
+		host string
+		want bool
+	}{
+		{list: "", host: "github.com", want: false},
+		{list: "*", host: "github.com", want: true},
+		{list: "github.com", host: "github.com", want: true},
+		{list: "github.com", host: "api.github.com", want: true},
+		{list: ".github.com", host: "api.github.com", want: true},
+		{list: "*.github.com", host: "api.github.com", want: true},
+		{list: "github.com", host: "notgithub.com", want: false},
+		{list: "gitlab.com, GitHub.com:443", host: "github.com", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list+"/"+tt.host, func(t *testing.T) {
+			if got := noProxy(tt.list, tt.host); got != tt.want {
+				t.Errorf("noProxy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
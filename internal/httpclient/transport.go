@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Transport sets the User-Agent header and, when Trace is set, logs requests
+// and connection details
+type Transport struct {
+	Base      http.RoundTripper
+	UserAgent string
+	Trace     bool
+	Logger    zerolog.Logger
+}
+
+// RoundTrip implements http.RoundTripper
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" && t.UserAgent != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.UserAgent)
+	}
+
+	if !t.Trace {
+		return t.Base.RoundTrip(req)
+	}
+
+	logger := t.Logger.With().Str("method", req.Method).Str("url", req.URL.Redacted()).Logger()
+	start := time.Now()
+
+	trace := &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			logger.Info().Msgf("getting connection to %s", hostPort)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			logger.Info().Msgf("got connection to %s (reused: %t)", info.Conn.RemoteAddr(), info.Reused)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			logger.Info().Err(info.Err).Msgf("resolved %v", info.Addrs)
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			logger.Info().Err(err).Msgf("TLS handshake done (version %s)", tls.VersionName(state.Version))
+		},
+		GotFirstResponseByte: func() {
+			logger.Info().Msgf("got first response byte after %s", time.Since(start).Round(time.Millisecond))
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	logger.Info().Msgf("sending request")
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		logger.Info().Err(err).Msgf("request failed after %s", time.Since(start).Round(time.Millisecond))
+		return nil, err
+	}
+
+	logger.Info().Msgf("got %s after %s", resp.Status, time.Since(start).Round(time.Millisecond))
+
+	return resp, nil
+}
@@ -0,0 +1,238 @@
+package list
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/auth"
+)
+
+const tagsPrefix = "refs/tags/"
+
+// GitTags contains what is required to get a list of tags from any git remote
+// over the smart HTTP protocol
+type GitTags struct {
+	url         string
+	prefix      string
+	exclude     string
+	auth        auth.Auth
+	credentials *auth.Resolver
+	client      *http.Client
+}
+
+// Get returns a list of available versions
+func (g GitTags) Get(ctx context.Context) ([]Release, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "GitTags.Get").Logger()
+
+	repo := strings.TrimSuffix(g.url, "/")
+
+	logger.Debug().Msgf("fetching tags from %s", repo)
+
+	tags, err := g.lsRefs(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var re *regexp.Regexp
+	if g.exclude != "" {
+		re, err = regexp.Compile(g.exclude)
+		if err != nil {
+			logger.Error().Err(err).Msgf("error compiling regular expression %q", g.exclude)
+			return nil, err
+		}
+	}
+
+	versions := []string{}
+
+	for _, sv := range tags {
+		if re != nil && re.Match([]byte(sv)) {
+			logger.Debug().Msgf("skipping version %q excluded by exclude regexp %q", sv, g.exclude)
+			continue
+		}
+
+		if g.prefix == "" {
+			versions = append(versions, sv)
+			continue
+		}
+
+		if strings.HasPrefix(sv, g.prefix) {
+			cleanv := strings.TrimPrefix(sv, g.prefix)
+			versions = append(versions, cleanv)
+		}
+	}
+
+	return newReleases(versions), nil
+}
+
+// lsRefs returns tag names in repo
+// Protocol v2 ls-refs is used when the server supports it; otherwise tags
+// are read from the v0 refs advertisement.
+func (g GitTags) lsRefs(ctx context.Context, repo string) ([]string, error) {
+	resp, err := g.do(ctx, http.MethodGet, repo+"/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+
+	// Smart servers start with a service announcement; skip it
+	line, err := readPktLine(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a smart HTTP git remote: %w", repo, err)
+	}
+	if strings.HasPrefix(string(line), "# service=") {
+		if _, err := readPktLine(r); err != nil && !errors.Is(err, errFlush) {
+			return nil, err
+		}
+		line, err = readPktLine(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.TrimSpace(string(line)) == "version 2" {
+		return g.lsRefsV2(ctx, repo)
+	}
+
+	// v0 advertisement; the first line holds capabilities after a NUL byte
+	tags := []string{}
+	for {
+		ref, _, _ := bytes.Cut(line, []byte{0})
+		if tag, ok := tagName(string(ref)); ok {
+			tags = append(tags, tag)
+		}
+
+		line, err = readPktLine(r)
+		if errors.Is(err, errFlush) {
+			return tags, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (g GitTags) lsRefsV2(ctx context.Context, repo string) ([]string, error) {
+	body := &bytes.Buffer{}
+	writePktLine(body, "command=ls-refs\n")
+	writePktLine(body, "agent=binenv\n")
+	body.WriteString("0001")
+	writePktLine(body, "ref-prefix "+tagsPrefix+"\n")
+	body.WriteString("0000")
+
+	resp, err := g.do(ctx, http.MethodPost, repo+"/git-upload-pack", body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	r := bufio.NewReader(resp.Body)
+
+	tags := []string{}
+	for {
+		line, err := readPktLine(r)
+		if errors.Is(err, errFlush) {
+			return tags, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// <oid> <refname>[ <attributes>]
+		fields := strings.Fields(string(line))
+		if len(fields) < 2 {
+			continue
+		}
+		if tag, ok := tagName(fields[1]); ok {
+			tags = append(tags, tag)
+		}
+	}
+}
+
+// do sends a request to the remote, advertising protocol v2 support
+func (g GitTags) do(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Git-Protocol", "version=2")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-git-upload-pack-request")
+		req.Header.Set("Accept", "application/x-git-upload-pack-result")
+	}
+
+	_, err = g.credentials.Authorize(req, g.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unable to list tags from %s: %s", g.url, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// tagName returns the tag name for ref, ignoring other refs and peeled tags
+// (<tag>^{})
+func tagName(ref string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if _, name, ok := strings.Cut(ref, " "); ok {
+		ref = name
+	}
+
+	if !strings.HasPrefix(ref, tagsPrefix) || strings.HasSuffix(ref, "^{}") {
+		return "", false
+	}
+
+	return strings.TrimPrefix(ref, tagsPrefix), true
+}
+
+// errFlush is returned when reading flush, delimiter or response-end
+// packets
+var errFlush = errors.New("flush packet")
+
+// readPktLine reads a pkt-line and returns its payload
+func readPktLine(r *bufio.Reader) ([]byte, error) {
+	hdr := make([]byte, 4)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, fmt.Errorf("unable to read pkt-line: %w", err)
+	}
+
+	size, err := strconv.ParseUint(string(hdr), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pkt-line length %q", hdr)
+	}
+	if size < 4 {
+		return nil, errFlush
+	}
+
+	payload := make([]byte, size-4)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("unable to read pkt-line: %w", err)
+	}
+
+	return payload, nil
+}
+
+func writePktLine(w *bytes.Buffer, s string) {
+	fmt.Fprintf(w, "%04x%s", len(s)+4, s)
+}
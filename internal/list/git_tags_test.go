@@ -0,0 +1,162 @@
+package list
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var fakeRefs = []string{
+	"HEAD",
+	"refs/heads/main",
+	"refs/tags/v1.0.0",
+	"refs/tags/v1.0.0^{}",
+	"refs/tags/v1.1.0-rc1",
+	"refs/tags/v1.1.0",
+	"refs/tags/nightly",
+}
+
+const fakeOID = "0123456789abcdef0123456789abcdef01234567"
+
+// fakeGitRemote serves fakeRefs over smart HTTP, speaking protocol v2 when
+// v2 is set and the client asks for it
+func fakeGitRemote(t *testing.T, v2 bool) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repo.git/info/refs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("service") != "git-upload-pack" {
+			http.Error(w, "dumb protocol not supported", http.StatusForbidden)
+			return
+		}
+
+		b := &bytes.Buffer{}
+		writePktLine(b, "# service=git-upload-pack\n")
+		b.WriteString("0000")
+
+		if v2 && r.Header.Get("Git-Protocol") == "version=2" {
+			writePktLine(b, "version 2\n")
+			writePktLine(b, "ls-refs=unborn\n")
+			b.WriteString("0000")
+		} else {
+			for i, ref := range fakeRefs {
+				if i == 0 {
+					writePktLine(b, fakeOID+" "+ref+"\x00multi_ack side-band-64k\n")
+					continue
+				}
+				writePktLine(b, fakeOID+" "+ref+"\n")
+			}
+			b.WriteString("0000")
+		}
+
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		w.Write(b.Bytes())
+	})
+	mux.HandleFunc("/repo.git/git-upload-pack", func(w http.ResponseWriter, r *http.Request) {
+		if !v2 || r.Method != http.MethodPost {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		// Check the command and honor ref-prefix
+		prefixes := []string{}
+		rd := bufio.NewReader(r.Body)
+		command := ""
+		for {
+			line, err := readPktLine(rd)
+			if errors.Is(err, errFlush) {
+				continue
+			}
+			if err != nil {
+				break
+			}
+			s := strings.TrimSpace(string(line))
+			switch {
+			case strings.HasPrefix(s, "command="):
+				command = strings.TrimPrefix(s, "command=")
+			case strings.HasPrefix(s, "ref-prefix "):
+				prefixes = append(prefixes, strings.TrimPrefix(s, "ref-prefix "))
+			}
+		}
+		if command != "ls-refs" {
+			http.Error(w, "unexpected command "+command, http.StatusBadRequest)
+			return
+		}
+
+		b := &bytes.Buffer{}
+		for _, ref := range fakeRefs {
+			if strings.HasSuffix(ref, "^{}") {
+				continue
+			}
+			for _, p := range prefixes {
+				if strings.HasPrefix(ref, p) {
+					writePktLine(b, fakeOID+" "+ref+"\n")
+					break
+				}
+			}
+		}
+		b.WriteString("0000")
+
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
+		w.Write(b.Bytes())
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestGitTags_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    List
+		v2      bool
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "protocol v2",
+			list: List{URL: "/repo.git", Prefix: "v"},
+			v2:   true,
+			want: []string{"1.0.0", "1.1.0-rc1", "1.1.0"},
+		},
+		{
+			name: "protocol v0",
+			list: List{URL: "/repo.git/", Prefix: "v"},
+			want: []string{"1.0.0", "1.1.0-rc1", "1.1.0"},
+		},
+		{
+			name: "exclude",
+			list: List{URL: "/repo.git", Exclude: "rc|nightly"},
+			v2:   true,
+			want: []string{"v1.0.0", "v1.1.0"},
+		},
+		{
+			name:    "not a git remote",
+			list:    List{URL: "/other.git"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := fakeGitRemote(t, tt.v2)
+			defer ts.Close()
+
+			l := tt.list
+			l.Type = "git-tags"
+			l.URL = ts.URL + l.URL
+
+			got, err := l.Factory(WithClient(ts.Client())).Get(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GitTags.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(Versions(got), tt.want) {
+				t.Errorf("GitTags.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
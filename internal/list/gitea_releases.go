@@ -0,0 +1,241 @@
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/auth"
+)
+
+// giteaPageSize is the number of releases requested per page; instances
+// may cap it lower
+const giteaPageSize = 50
+
+type giteaReleaseResponse []struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	HTMLURL     string    `json:"html_url"`
+	Assets      []struct {
+		Name string `json:"name"`
+	} `json:"assets"`
+}
+
+// GiteaRelease contains what is required to get a list of release from Gitea
+// (or Forgejo, e.g. Codeberg)
+type GiteaRelease struct {
+	url         string
+	prefix      string
+	exclude     string
+	versionFrom string
+	auth        auth.Auth
+	credentials *auth.Resolver
+	client      *http.Client
+}
+
+// Get returns a list of available versions
+func (g GiteaRelease) Get(ctx context.Context) ([]Release, error) {
+	return g.get(ctx, nil)
+}
+
+// GetNew returns available versions, stopping at the first page with a
+// known version
+func (g GiteaRelease) GetNew(ctx context.Context, known func(string) bool) ([]Release, error) {
+	return g.get(ctx, known)
+}
+
+func (g GiteaRelease) get(ctx context.Context, known func(string) bool) ([]Release, error) {
+	api, err := giteaReleasesURL(g.url)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		next     = 1
+		versions []Release
+		v        []Release
+	)
+
+	for next > 0 {
+		v, next, err = g.doGet(ctx, api, next)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v...)
+		if anyKnown(v, known) {
+			break
+		}
+	}
+
+	return versions, nil
+}
+
+func (g GiteaRelease) doGet(ctx context.Context, api string, page int) ([]Release, int, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "GiteaRelease.doGet").Logger()
+
+	logger.Debug().Msgf("fetching versions from %s", api)
+
+	pageURL, err := setQuery(api, url.Values{
+		"page":  {strconv.Itoa(page)},
+		"limit": {strconv.Itoa(giteaPageSize)},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Gitea has no canonical host, so tokens only come from token_env, auth
+	// or the credentials file
+	_, err = g.credentials.Authorize(req, g.auth)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("unable to list releases from %s: %s", api, resp.Status)
+	}
+
+	gr := giteaReleaseResponse{}
+	err = json.Unmarshal(body, &gr)
+	if err != nil {
+		logger.Error().Err(err).Msgf("error unmarshalling gitea response for %s", api)
+		return nil, 0, err
+	}
+
+	var re *regexp.Regexp
+	if g.exclude != "" {
+		re, err = regexp.Compile(g.exclude)
+		if err != nil {
+			logger.Error().Err(err).Msgf("error compiling regular expression %q", g.exclude)
+			return nil, 0, err
+		}
+	}
+
+	versions := []Release{}
+
+	for _, v := range gr {
+		if v.Draft {
+			continue
+		}
+
+		sv := v.TagName
+		switch g.versionFrom {
+		case "name":
+			sv = v.Name
+		}
+
+		if re != nil && re.Match([]byte(sv)) {
+			logger.Debug().Msgf("skipping version %q excluded by exclude regexp %q", sv, g.exclude)
+			continue
+		}
+
+		if g.prefix != "" {
+			if !strings.HasPrefix(sv, g.prefix) {
+				continue
+			}
+			sv = strings.TrimPrefix(sv, g.prefix)
+		}
+
+		r := Release{
+			Version:    sv,
+			Prerelease: v.Prerelease,
+			Published:  v.PublishedAt,
+			URL:        v.HTMLURL,
+		}
+		for _, a := range v.Assets {
+			r.Assets = append(r.Assets, a.Name)
+		}
+		versions = append(versions, r)
+	}
+
+	return versions, giteaNextPage(resp, page, len(gr)), nil
+}
+
+// giteaNextPage returns the next page to fetch, or 0 when done
+// The Link header is used when present, X-Total-Count otherwise.
+func giteaNextPage(resp *http.Response, page, count int) int {
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return 0
+		}
+		next, err := strconv.Atoi(u.Query().Get("page"))
+		if err != nil || next <= page {
+			return 0
+		}
+		return next
+	}
+
+	if resp.Header.Get("Link") != "" || count == 0 {
+		return 0
+	}
+
+	total, err := strconv.Atoi(resp.Header.Get("X-Total-Count"))
+	if err != nil || page*count >= total {
+		return 0
+	}
+
+	return page + 1
+}
+
+// giteaReleasesURL returns the releases API endpoint for u, which can be
+// either the API endpoint itself or the repository URL (e.g.
+// https://codeberg.org/owner/repo, or https://example.org/gitea/owner/repo
+// for instances served under a path)
+func giteaReleasesURL(u string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(u, "/"))
+	if err != nil {
+		return "", err
+	}
+
+	if strings.Contains(parsed.Path, "/api/v1/") {
+		return parsed.String(), nil
+	}
+
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) < 2 {
+		return "", fmt.Errorf("invalid gitea repository URL %q: expecting <base>/<owner>/<repo>", u)
+	}
+
+	owner, repo := parts[len(parts)-2], strings.TrimSuffix(parts[len(parts)-1], ".git")
+	base := strings.Join(parts[:len(parts)-2], "/")
+	if base != "" {
+		base = "/" + base
+	}
+
+	parsed.Path = fmt.Sprintf("%s/api/v1/repos/%s/%s/releases", base, owner, repo)
+
+	return parsed.String(), nil
+}
@@ -0,0 +1,165 @@
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/devops-works/binenv/internal/auth"
+)
+
+// fakeGitea serves releases for owner/repo, 2 per page, and requires token
+// when set
+func fakeGitea(t *testing.T, token string, link bool) *httptest.Server {
+	t.Helper()
+
+	type release struct {
+		TagName string `json:"tag_name"`
+		Name    string `json:"name"`
+		Draft   bool   `json:"draft"`
+	}
+	releases := []release{
+		{TagName: "v1.3.0", Name: "Release 1.3.0"},
+		{TagName: "v1.2.0-rc1", Name: "Release 1.2.0-rc1"},
+		{TagName: "v1.2.0", Name: "Release 1.2.0", Draft: true},
+		{TagName: "v1.1.0", Name: "Release 1.1.0"},
+		{TagName: "other-1.0.0", Name: "Other 1.0.0"},
+	}
+	const perPage = 2
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/git/api/v1/repos/owner/repo/releases", func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "token "+token {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 {
+			page = 1
+		}
+		start := (page - 1) * perPage
+		end := start + perPage
+		if start > len(releases) {
+			start = len(releases)
+		}
+		if end > len(releases) {
+			end = len(releases)
+		}
+
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(releases)))
+		if link && end < len(releases) {
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?limit=%d&page=%d>; rel="next",<http://%s%s?limit=%d&page=3>; rel="last"`,
+				r.Host, r.URL.Path, perPage, page+1, r.Host, r.URL.Path, perPage))
+		}
+		json.NewEncoder(w).Encode(releases[start:end])
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestGiteaRelease_Get(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    List
+		token   string
+		link    bool
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "repository URL with Link pagination",
+			list: List{Type: "gitea-releases", URL: "/git/owner/repo", Prefix: "v"},
+			link: true,
+			want: []string{"1.3.0", "1.2.0-rc1", "1.1.0"},
+		},
+		{
+			name: "API URL with X-Total-Count pagination",
+			list: List{Type: "gitea-releases", URL: "/git/api/v1/repos/owner/repo/releases", Prefix: "v"},
+			want: []string{"1.3.0", "1.2.0-rc1", "1.1.0"},
+		},
+		{
+			name: "exclude",
+			list: List{Type: "gitea-releases", URL: "/git/owner/repo.git", Prefix: "v", Exclude: "-rc"},
+			link: true,
+			want: []string{"1.3.0", "1.1.0"},
+		},
+		{
+			name: "version from name",
+			list: List{Type: "gitea-releases", URL: "/git/owner/repo", Prefix: "Other ", VersionFrom: "name"},
+			link: true,
+			want: []string{"1.0.0"},
+		},
+		{
+			name:  "token",
+			list:  List{Type: "gitea-releases", URL: "/git/owner/repo", Prefix: "v", TokenEnv: "BINENV_TEST_GITEA_TOKEN"},
+			token: "s3cr3t",
+			link:  true,
+			want:  []string{"1.3.0", "1.2.0-rc1", "1.1.0"},
+		},
+		{
+			name:    "missing token",
+			list:    List{Type: "gitea-releases", URL: "/git/owner/repo", Auth: auth.Auth{Type: auth.TypeGithub, TokenEnv: "BINENV_TEST_GITEA_UNSET"}},
+			token:   "s3cr3t",
+			wantErr: true,
+		},
+		{
+			name:    "unauthorized",
+			list:    List{Type: "gitea-releases", URL: "/git/owner/repo"},
+			token:   "s3cr3t",
+			wantErr: true,
+		},
+	}
+
+	t.Setenv("BINENV_TEST_GITEA_TOKEN", "s3cr3t")
+	t.Setenv("BINENV_TEST_GITEA_UNSET", "")
+	// Must not be sent to arbitrary hosts
+	t.Setenv("GITEA_TOKEN", "s3cr3t")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := fakeGitea(t, tt.token, tt.link)
+			defer ts.Close()
+
+			l := tt.list
+			l.URL = ts.URL + l.URL
+
+			got, err := l.Factory(WithClient(ts.Client())).Get(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GiteaRelease.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(Versions(got), tt.want) {
+				t.Errorf("GiteaRelease.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_giteaReleasesURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"https://codeberg.org/owner/repo", "https://codeberg.org/api/v1/repos/owner/repo/releases", false},
+		{"https://codeberg.org/owner/repo/", "https://codeberg.org/api/v1/repos/owner/repo/releases", false},
+		{"https://example.org/gitea/owner/repo.git", "https://example.org/gitea/api/v1/repos/owner/repo/releases", false},
+		{"https://codeberg.org/api/v1/repos/owner/repo/releases", "https://codeberg.org/api/v1/repos/owner/repo/releases", false},
+		{"https://codeberg.org/owner", "", true},
+	}
+	for _, tt := range tests {
+		got, err := giteaReleasesURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("giteaReleasesURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("giteaReleasesURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package list
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/auth"
+)
+
+const (
+	// githubGraphQLEndpoint is the GitHub GraphQL API
+	githubGraphQLEndpoint = "https://api.github.com/graphql"
+
+	// githubGraphQLBatch is the number of repositories queried at once
+	githubGraphQLBatch = 50
+
+	// githubGraphQLPage is the number of releases fetched per repository and
+	// request (maximum allowed by GitHub)
+	githubGraphQLPage = 100
+
+	// githubGraphQLAssets is the number of asset names fetched per release;
+	// with the limits above, a query stays under the 500,000 nodes limit
+	// Assets of releases having more are left unknown.
+	githubGraphQLAssets = 50
+)
+
+// ErrGithubGraphQLUnavailable is returned when the GraphQL API can not be
+// used (it always requires a token)
+var ErrGithubGraphQLUnavailable = errors.New("github graphql API requires a token")
+
+// githubRepoURL matches github-releases API URLs the GraphQL path can handle
+var githubRepoURL = regexp.MustCompile(`^https://api\.github\.com/repos/([^/]+)/([^/]+)/releases/?$`)
+
+// githubRepo returns the owner and name of the github.com repository listed
+// by l; GitHub Enterprise Server repositories are not supported
+func githubRepo(l List) (string, string, bool) {
+	api, err := githubReleasesURL(l.URL)
+	if err != nil {
+		return "", "", false
+	}
+
+	m := githubRepoURL.FindStringSubmatch(api)
+	if m == nil {
+		return "", "", false
+	}
+
+	return m[1], m[2], true
+}
+
+// GithubGraphQL fetches releases for many github-releases lists in a few
+// GraphQL requests, instead of paginated REST requests for each of them
+type GithubGraphQL struct {
+	endpoint    string
+	credentials *auth.Resolver
+	client      *http.Client
+}
+
+// NewGithubGraphQL returns a GraphQL batch lister
+func NewGithubGraphQL(o ...Option) GithubGraphQL {
+	opts := newOptions(o...)
+
+	return GithubGraphQL{
+		endpoint:    githubGraphQLEndpoint,
+		credentials: opts.credentials,
+		client:      opts.client,
+	}
+}
+
+// Supports returns true if l can be listed using GraphQL
+// Lists with their own auth are left to the REST lister.
+func (g GithubGraphQL) Supports(l List) bool {
+	if l.Type != "github-releases" || !l.Auth.IsZero() || l.TokenEnv != "" {
+		return false
+	}
+
+	_, _, ok := githubRepo(l)
+	return ok
+}
+
+// graphqlRepo tracks a repository being fetched
+type graphqlRepo struct {
+	owner, name string
+	cursor      string
+	releases    ghReleaseResponse
+	failed      bool
+}
+
+// Get returns releases for supported lists, keyed like lists
+// Lists that could not be fetched (unsupported, missing repository...) are
+// not in the result, so they can be fetched another way. An error is
+// returned when the API can not be used at all.
+func (g GithubGraphQL) Get(ctx context.Context, lists map[string]List) (map[string][]Release, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "GithubGraphQL.Get").Logger()
+
+	header, err := g.authorization()
+	if err != nil {
+		return nil, err
+	}
+
+	// Several distributions may share a repository
+	repos := map[string]*graphqlRepo{}
+	for _, l := range lists {
+		if !g.Supports(l) {
+			continue
+		}
+		owner, name, _ := githubRepo(l)
+		key := strings.ToLower(owner + "/" + name)
+		if _, ok := repos[key]; !ok {
+			repos[key] = &graphqlRepo{owner: owner, name: name}
+		}
+	}
+
+	pending := []*graphqlRepo{}
+	for _, r := range repos {
+		pending = append(pending, r)
+	}
+
+	requests := 0
+	for len(pending) > 0 {
+		n := githubGraphQLBatch
+		if n > len(pending) {
+			n = len(pending)
+		}
+		batch := pending[:n]
+		pending = pending[n:]
+
+		more, err := g.query(ctx, header, batch)
+		if err != nil {
+			return nil, err
+		}
+		requests++
+
+		// Repositories with more releases are queried again
+		pending = append(pending, more...)
+	}
+
+	logger.Debug().Msgf("fetched releases for %d repositories in %d requests", len(repos), requests)
+
+	res := make(map[string][]Release)
+	for dist, l := range lists {
+		if !g.Supports(l) {
+			continue
+		}
+		owner, name, _ := githubRepo(l)
+		r := repos[strings.ToLower(owner+"/"+name)]
+		if r.failed {
+			continue
+		}
+
+		gr := GithubRelease{
+			url:         l.URL,
+			prefix:      l.Prefix,
+			exclude:     l.Exclude,
+			versionFrom: l.VersionFrom,
+		}
+		releases, err := gr.releases(ctx, r.releases)
+		if err != nil {
+			continue
+		}
+		releases, err = extractVersions(ctx, l.VersionRegex, releases)
+		if err != nil {
+			continue
+		}
+		res[dist] = releases
+	}
+
+	return res, nil
+}
+
+// authorization returns the Authorization header value
+func (g GithubGraphQL) authorization() (map[string]string, error) {
+	c, err := g.credentials.Resolve(auth.Auth{}, githubAPIHost)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsZero() {
+		return c.Header(), nil
+	}
+
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return map[string]string{"Authorization": "bearer " + token}, nil
+	}
+
+	return nil, ErrGithubGraphQLUnavailable
+}
+
+type graphqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Type    string        `json:"type"`
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	} `json:"errors"`
+}
+
+type graphqlReleases struct {
+	Releases struct {
+		Nodes []struct {
+			TagName       string    `json:"tagName"`
+			Name          string    `json:"name"`
+			IsDraft       bool      `json:"isDraft"`
+			IsPrerelease  bool      `json:"isPrerelease"`
+			PublishedAt   time.Time `json:"publishedAt"`
+			URL           string    `json:"url"`
+			ReleaseAssets struct {
+				TotalCount int `json:"totalCount"`
+				Nodes      []struct {
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"releaseAssets"`
+		} `json:"nodes"`
+		PageInfo struct {
+			HasNextPage bool   `json:"hasNextPage"`
+			EndCursor   string `json:"endCursor"`
+		} `json:"pageInfo"`
+	} `json:"releases"`
+}
+
+// query fetches the next page of releases for repos, and returns the
+// repositories having more releases
+func (g GithubGraphQL) query(ctx context.Context, header map[string]string, repos []*graphqlRepo) ([]*graphqlRepo, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "GithubGraphQL.query").Logger()
+
+	q := &strings.Builder{}
+	q.WriteString("query {\n")
+	for i, r := range repos {
+		after := ""
+		if r.cursor != "" {
+			after = ", after: " + quote(r.cursor)
+		}
+		fmt.Fprintf(q, "  r%d: repository(owner: %s, name: %s) { releases(first: %d%s, orderBy: {field: CREATED_AT, direction: DESC}) { nodes { tagName name isDraft isPrerelease publishedAt url releaseAssets(first: %d) { totalCount nodes { name } } } pageInfo { hasNextPage endCursor } } }\n",
+			i, quote(r.owner), quote(r.name), githubGraphQLPage, after, githubGraphQLAssets)
+	}
+	q.WriteString("  rateLimit { cost remaining resetAt }\n}\n")
+
+	payload, err := json.Marshal(map[string]string{"query": q.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.Header.Get("X-Ratelimit-Remaining") == "0" {
+			return nil, handleRatelimit(resp)
+		}
+		return nil, fmt.Errorf("github graphql request failed: %s", resp.Status)
+	}
+
+	gr := graphqlResponse{}
+	err = json.Unmarshal(body, &gr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github graphql response: %w", err)
+	}
+
+	for _, e := range gr.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return nil, fmt.Errorf("%w: %s", ErrGithubRateLimited, e.Message)
+		}
+		logger.Debug().Msgf("github graphql error for %v: %s", e.Path, e.Message)
+	}
+	if gr.Data == nil {
+		return nil, fmt.Errorf("github graphql request failed: %d errors", len(gr.Errors))
+	}
+
+	if rl, ok := gr.Data["rateLimit"]; ok {
+		logger.Debug().Msgf("github graphql rate limit: %s", rl)
+	}
+
+	more := []*graphqlRepo{}
+	for i, r := range repos {
+		raw, ok := gr.Data[fmt.Sprintf("r%d", i)]
+		if !ok || string(raw) == "null" {
+			// Unknown or inaccessible repository
+			r.failed = true
+			continue
+		}
+
+		rel := graphqlReleases{}
+		if err := json.Unmarshal(raw, &rel); err != nil {
+			r.failed = true
+			continue
+		}
+
+		for _, n := range rel.Releases.Nodes {
+			gr := ghRelease{
+				TagName:     n.TagName,
+				Name:        n.Name,
+				Prerelease:  n.IsPrerelease,
+				Draft:       n.IsDraft,
+				PublishedAt: n.PublishedAt,
+				HTMLURL:     n.URL,
+			}
+			// A truncated list would tell missing assets are not published
+			if n.ReleaseAssets.TotalCount <= len(n.ReleaseAssets.Nodes) {
+				for _, a := range n.ReleaseAssets.Nodes {
+					gr.Assets = append(gr.Assets, ghAsset{Name: a.Name})
+				}
+			}
+			r.releases = append(r.releases, gr)
+		}
+
+		if rel.Releases.PageInfo.HasNextPage {
+			r.cursor = rel.Releases.PageInfo.EndCursor
+			more = append(more, r)
+		}
+	}
+
+	return more, nil
+}
+
+// quote returns s as a GraphQL string literal
+func quote(s string) string {
+	js, _ := json.Marshal(s)
+	return string(js)
+}
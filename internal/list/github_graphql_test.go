@@ -0,0 +1,197 @@
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeGraphQL answers aliased repository releases queries for repos
+// (owner/name -> tags, most recent first)
+func fakeGraphQL(t *testing.T, repos map[string][]string, requests *int) *httptest.Server {
+	t.Helper()
+
+	alias := regexp.MustCompile(`(r\d+): repository\(owner: "([^"]+)", name: "([^"]+)"\) \{ releases\(first: (\d+)(?:, after: "(\d+)")?`)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+
+		if r.Header.Get("Authorization") != "bearer s3cr3t" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+
+		body := struct {
+			Query string `json:"query"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data := map[string]interface{}{
+			"rateLimit": map[string]interface{}{"cost": 1, "remaining": 4999},
+		}
+		errs := []map[string]interface{}{}
+
+		for _, m := range alias.FindAllStringSubmatch(body.Query, -1) {
+			tags, ok := repos[m[2]+"/"+m[3]]
+			if !ok {
+				data[m[1]] = nil
+				errs = append(errs, map[string]interface{}{
+					"type":    "NOT_FOUND",
+					"path":    []string{m[1]},
+					"message": fmt.Sprintf("Could not resolve to a Repository with the name '%s/%s'.", m[2], m[3]),
+				})
+				continue
+			}
+
+			first, _ := strconv.Atoi(m[4])
+			start, _ := strconv.Atoi(m[5])
+			end := start + first
+			if end > len(tags) {
+				end = len(tags)
+			}
+
+			nodes := []map[string]interface{}{}
+			for _, tag := range tags[start:end] {
+				// Releases with too many assets to be listed at once
+				total := 1
+				if strings.HasSuffix(tag, "-full") {
+					total = 200
+				}
+				nodes = append(nodes, map[string]interface{}{
+					"tagName":      tag,
+					"name":         "Release " + tag,
+					"isDraft":      tag == "v0.0.0-draft",
+					"isPrerelease": strings.Contains(tag, "-rc"),
+					"publishedAt":  "2024-03-01T10:00:00Z",
+					"url":          "https://github.com/" + m[2] + "/" + m[3] + "/releases/tag/" + tag,
+					"releaseAssets": map[string]interface{}{
+						"totalCount": total,
+						"nodes":      []map[string]string{{"name": m[3] + "_linux_amd64.tar.gz"}},
+					},
+				})
+			}
+			data[m[1]] = map[string]interface{}{
+				"releases": map[string]interface{}{
+					"nodes": nodes,
+					"pageInfo": map[string]interface{}{
+						"hasNextPage": end < len(tags),
+						"endCursor":   strconv.Itoa(end),
+					},
+				},
+			}
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data, "errors": errs})
+	}))
+}
+
+func TestGithubGraphQL_Get(t *testing.T) {
+	many := []string{}
+	for i := 150; i > 0; i-- {
+		many = append(many, fmt.Sprintf("v1.%d.0", i))
+	}
+
+	requests := 0
+	ts := fakeGraphQL(t, map[string][]string{
+		"org/tool":  {"v2.0.0-full", "v2.0.0", "v2.0.0-rc1", "v0.0.0-draft", "v1.0.0"},
+		"org/other": {"other-1.1.0", "other-1.0.0"},
+		"org/many":  many,
+	}, &requests)
+	defer ts.Close()
+
+	lists := map[string]List{
+		"tool":    {Type: "github-releases", URL: "https://api.github.com/repos/org/tool/releases", Prefix: "v"},
+		"tool-rc": {Type: "github-releases", URL: "https://api.github.com/repos/org/tool/releases", Exclude: "rc"},
+		"other":   {Type: "github-releases", URL: "https://api.github.com/repos/org/other/releases", Prefix: "Release other-", VersionFrom: "name"},
+		"many":    {Type: "github-releases", URL: "https://api.github.com/repos/org/many/releases/"},
+		"missing": {Type: "github-releases", URL: "https://api.github.com/repos/org/missing/releases"},
+		"static":  {Type: "static", Versions: []string{"1.0.0"}},
+		"repo":    {Type: "github-releases", URL: "https://github.com/org/tool", Prefix: "v"},
+		"ghe":     {Type: "github-releases", URL: "https://ghe.example.org/org/tool"},
+		"token":   {Type: "github-releases", URL: "https://github.com/org/tool", TokenEnv: "TOOL_TOKEN"},
+	}
+
+	g := NewGithubGraphQL(WithClient(ts.Client()))
+	g.endpoint = ts.URL
+
+	t.Setenv("GITHUB_TOKEN", "")
+	if _, err := g.Get(context.Background(), lists); !errors.Is(err, ErrGithubGraphQLUnavailable) {
+		t.Fatalf("GithubGraphQL.Get() error = %v, want %v", err, ErrGithubGraphQLUnavailable)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "s3cr3t")
+	got, err := g.Get(context.Background(), lists)
+	if err != nil {
+		t.Fatalf("GithubGraphQL.Get() error = %v", err)
+	}
+
+	// First batch for all repositories, then one for the second page of
+	// org/many
+	if requests != 2 {
+		t.Errorf("GithubGraphQL.Get() sent %d requests, want 2", requests)
+	}
+
+	rc := got["tool"][2]
+	if rc.Version != "2.0.0-rc1" || !rc.Prerelease || rc.Published.IsZero() ||
+		rc.URL != "https://github.com/org/tool/releases/tag/v2.0.0-rc1" ||
+		!reflect.DeepEqual(rc.Assets, []string{"tool_linux_amd64.tar.gz"}) {
+		t.Errorf("GithubGraphQL.Get() release = %+v, want metadata", rc)
+	}
+
+	// Assets are unknown when they can not all be listed
+	if full := got["tool"][0]; full.Version != "2.0.0-full" || full.Assets != nil {
+		t.Errorf("GithubGraphQL.Get() release = %+v, want unknown assets", full)
+	}
+
+	// Drafts are kept, like the REST lister does
+	if draft := got["tool"][3]; draft.Version != "0.0.0-draft" || !draft.Draft {
+		t.Errorf("GithubGraphQL.Get() release = %+v, want draft", draft)
+	}
+
+	if len(got["many"]) != 150 {
+		t.Errorf("GithubGraphQL.Get() returned %d versions for many, want 150", len(got["many"]))
+	}
+	delete(got, "many")
+
+	want := map[string][]string{
+		"tool":    {"2.0.0-full", "2.0.0", "2.0.0-rc1", "0.0.0-draft", "1.0.0"},
+		"repo":    {"2.0.0-full", "2.0.0", "2.0.0-rc1", "0.0.0-draft", "1.0.0"},
+		"tool-rc": {"v2.0.0-full", "v2.0.0", "v0.0.0-draft", "v1.0.0"},
+		"other":   {"1.1.0", "1.0.0"},
+	}
+	versions := make(map[string][]string)
+	for dist, releases := range got {
+		versions[dist] = Versions(releases)
+	}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("GithubGraphQL.Get() = %v, want %v", versions, want)
+	}
+}
+
+func TestGithubGraphQL_Get_unauthorized(t *testing.T) {
+	requests := 0
+	ts := fakeGraphQL(t, map[string][]string{}, &requests)
+	defer ts.Close()
+
+	g := NewGithubGraphQL(WithClient(ts.Client()))
+	g.endpoint = ts.URL
+
+	t.Setenv("GITHUB_TOKEN", "wrong")
+	_, err := g.Get(context.Background(), map[string]List{
+		"tool": {Type: "github-releases", URL: "https://api.github.com/repos/org/tool/releases"},
+	})
+	if err == nil {
+		t.Errorf("GithubGraphQL.Get() succeeded with a wrong token")
+	}
+}
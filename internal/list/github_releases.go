@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -14,6 +15,9 @@ import (
 	"time"
 
 	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/auth"
+	"github.com/devops-works/binenv/internal/httpcache"
 )
 
 var (
@@ -27,9 +31,29 @@ var (
 	GithubLowRateLimit = 4
 )
 
-type ghReleaseResponse []struct {
-	TagName string `json:"tag_name"`
-	Name    string `json:"name"`
+// githubAPIHost is the github.com API host
+const githubAPIHost = "api.github.com"
+
+type ghRelease struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Prerelease  bool      `json:"prerelease"`
+	Draft       bool      `json:"draft"`
+	PublishedAt time.Time `json:"published_at"`
+	HTMLURL     string    `json:"html_url"`
+	Assets      []ghAsset `json:"assets"`
+}
+
+type ghAsset struct {
+	Name string `json:"name"`
+}
+
+type ghReleaseResponse []ghRelease
+
+// ghPage is what is kept in the validators cache for a page of releases
+type ghPage struct {
+	Releases ghReleaseResponse `json:"releases"`
+	Next     int               `json:"next"`
 }
 
 // GithubRelease contains what is required to get a list of release from Github
@@ -38,80 +62,187 @@ type GithubRelease struct {
 	prefix      string
 	exclude     string
 	versionFrom string
+	auth        auth.Auth
+	credentials *auth.Resolver
+	client      *http.Client
+	validators  *httpcache.Cache
 }
 
 // Get returns a list of available versions
-func (g GithubRelease) Get(ctx context.Context) ([]string, error) {
-	// logger := zerolog.Ctx(ctx).With().Str("func", "GithubRelease.Get").Logger()
+// When validators are available, pages are requested conditionally. If the
+// first page did not change, the release list did not either and following
+// pages are taken from the validators cache without any request.
+func (g GithubRelease) Get(ctx context.Context) ([]Release, error) {
+	return g.get(ctx, nil)
+}
+
+// GetNew returns available versions, stopping at the first page with a
+// known version
+func (g GithubRelease) GetNew(ctx context.Context, known func(string) bool) ([]Release, error) {
+	return g.get(ctx, known)
+}
+
+func (g GithubRelease) get(ctx context.Context, known func(string) bool) ([]Release, error) {
+	// logger := zerolog.Ctx(ctx).With().Str("func", "GithubRelease.get").Logger()
+
+	api, err := githubReleasesURL(g.url)
+	if err != nil {
+		return nil, err
+	}
+	g.url = api
 
 	var (
-		next     = 1
-		versions []string
-		v        []string
-		err      error
+		next      = 1
+		versions  []Release
+		v         []Release
+		unchanged bool
+		// Pages served close to the rate limit are valid, so the error is
+		// returned along with versions
+		closeErr error
 	)
 
 	for next > 0 {
-		v, next, err = g.doGet(ctx, next)
-		if err != nil {
+		v, next, unchanged, err = g.doGet(ctx, next, unchanged)
+		if rateLimitClose(err) {
+			closeErr = err
+		} else if err != nil {
 			return nil, err
 		}
 		versions = append(versions, v...)
+		if anyKnown(v, known) {
+			break
+		}
 	}
 
-	return versions, err
+	return versions, closeErr
 }
 
-func (g GithubRelease) doGet(ctx context.Context, page int) ([]string, int, error) {
+// doGet returns versions in page, the next page number (0 if none), and
+// whether the page is unchanged since the last update
+// When unchanged is set (the previous page was not modified), the cached
+// page is used without sending a request.
+func (g GithubRelease) doGet(ctx context.Context, page int, unchanged bool) ([]Release, int, bool, error) {
 	logger := zerolog.Ctx(ctx).With().Str("func", "GithubRelease.doGet").Logger()
 
 	next := 0
-	client := &http.Client{}
+	pageURL, err := setQuery(g.url, url.Values{"page": {strconv.Itoa(page)}})
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if unchanged {
+		if e, ok := g.validators.Get(pageURL); ok {
+			versions, next, err := g.cachedPage(ctx, e)
+			return versions, next, err == nil, err
+		}
+	}
 
 	logger.Debug().Msgf("fetching versions from %s", g.url)
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?page=%d", g.url, page), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
 	}
+	cached, conditional := g.validators.Prepare(req)
 
-	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+	authorized, err := g.credentials.Authorize(req, g.auth)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if token := githubToken(req.URL.Host); token != "" && !authorized {
 		req.Header.Set("Authorization", "token "+token)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		resp.Body.Close()
+		logger.Debug().Msgf("page %d of %s not modified", page, g.url)
+		versions, next, err := g.cachedPage(ctx, cached)
+		// Only an unchanged first page tells that the whole list is unchanged
+		return versions, next, err == nil && (page == 1 || unchanged), err
 	}
 
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
 	}
 
 	// Check if we are already rate limited
 	if isRateLimited(resp) {
-		return nil, 0, handleRatelimit(resp)
+		return nil, 0, false, handleRatelimit(resp)
 	}
 
 	gr := ghReleaseResponse{}
 	err = json.Unmarshal([]byte(body), &gr)
 	if err != nil {
 		logger.Error().Err(err).Msgf("error unmarshalling github response for %s", g.url)
+		return nil, 0, false, err
+	}
+
+	versions, err := g.releases(ctx, gr)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if len(resp.Header["Link"]) > 0 && strings.Contains(resp.Header["Link"][0], "rel=\"next\"") {
+		re := regexp.MustCompile(`page=(\d*)>; rel="next"`)
+		match := re.FindStringSubmatch(resp.Header["Link"][0])
+		next, err = strconv.Atoi(match[1])
+
+		if err != nil {
+			return nil, 0, false, err
+		}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		err = g.validators.Set(resp, ghPage{Releases: gr, Next: next})
+		if err != nil {
+			logger.Warn().Err(err).Msgf("unable to cache validators for %s", pageURL)
+		}
+	}
+
+	if isRateLimitClose(resp) {
+		return versions, next, false, handleRatelimit(resp)
+	}
+	return versions, next, false, nil
+}
+
+// cachedPage returns versions and next page from a validators cache entry
+func (g GithubRelease) cachedPage(ctx context.Context, e httpcache.Entry) ([]Release, int, error) {
+	p := ghPage{}
+	if err := json.Unmarshal(e.Data, &p); err != nil {
+		return nil, 0, fmt.Errorf("invalid cached page for %s: %w", g.url, err)
+	}
+
+	versions, err := g.releases(ctx, p.Releases)
+	if err != nil {
 		return nil, 0, err
 	}
 
+	return versions, p.Next, nil
+}
+
+// releases extracts releases according to versionFrom, exclude and prefix
+func (g GithubRelease) releases(ctx context.Context, gr ghReleaseResponse) ([]Release, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "GithubRelease.releases").Logger()
+
 	var re *regexp.Regexp
 	if g.exclude != "" {
+		var err error
 		re, err = regexp.Compile(g.exclude)
 		if err != nil {
 			logger.Error().Err(err).Msgf("error compiling regular expression %q", g.exclude)
-			return nil, 0, err
+			return nil, err
 		}
 	}
 
-	versions := []string{}
+	releases := []Release{}
 
 	for _, v := range gr {
 		sv := v.TagName
@@ -125,31 +256,81 @@ func (g GithubRelease) doGet(ctx context.Context, page int) ([]string, int, erro
 			continue
 		}
 
-		if g.prefix == "" {
-			versions = append(versions, sv)
-			continue
+		if g.prefix != "" {
+			if !strings.HasPrefix(sv, g.prefix) {
+				continue
+			}
+			sv = strings.TrimPrefix(sv, g.prefix)
 		}
 
-		if strings.HasPrefix(sv, g.prefix) {
-			cleanv := strings.TrimPrefix(sv, g.prefix)
-			versions = append(versions, cleanv)
+		r := Release{
+			Version:    sv,
+			Prerelease: v.Prerelease,
+			Draft:      v.Draft,
+			Published:  v.PublishedAt,
+			URL:        v.HTMLURL,
+		}
+		for _, a := range v.Assets {
+			r.Assets = append(r.Assets, a.Name)
 		}
+		releases = append(releases, r)
 	}
 
-	if len(resp.Header["Link"]) > 0 && strings.Contains(resp.Header["Link"][0], "rel=\"next\"") {
-		re := regexp.MustCompile(`page=(\d*)>; rel="next"`)
-		match := re.FindStringSubmatch(resp.Header["Link"][0])
-		next, err = strconv.Atoi(match[1])
+	return releases, nil
+}
 
-		if err != nil {
-			return nil, 0, err
-		}
+// githubReleasesURL returns the releases API endpoint for u, which can be
+// either the repository URL (e.g. https://github.com/owner/repo, or
+// https://ghe.example.org/owner/repo for GitHub Enterprise Server, which
+// serves its API under /api/v3) or any other URL, used as is
+func githubReleasesURL(u string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(u, "/"))
+	if err != nil {
+		return "", err
 	}
 
-	if isRateLimitClose(resp) {
-		return versions, next, handleRatelimit(resp)
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) == 3 && parts[2] == "releases" {
+		parts = parts[:2]
+	}
+	if parsed.Host == githubAPIHost || len(parts) != 2 || parts[0] == "repos" {
+		return parsed.String(), nil
 	}
-	return versions, next, nil
+
+	owner, repo := parts[0], strings.TrimSuffix(parts[1], ".git")
+	if strings.EqualFold(parsed.Host, "github.com") || strings.EqualFold(parsed.Host, "www.github.com") {
+		return fmt.Sprintf("https://%s/repos/%s/%s/releases", githubAPIHost, owner, repo), nil
+	}
+
+	parsed.Path = fmt.Sprintf("/api/v3/repos/%s/%s/releases", owner, repo)
+
+	return parsed.String(), nil
+}
+
+// githubToken returns the token from the environment for the API host:
+// GITHUB_TOKEN for github.com, GH_ENTERPRISE_TOKEN or GITHUB_ENTERPRISE_TOKEN
+// for the GitHub Enterprise Server host set in GH_HOST (like the gh CLI)
+// Tokens for other hosts must be set in the credentials file.
+func githubToken(host string) string {
+	if host == githubAPIHost {
+		return os.Getenv("GITHUB_TOKEN")
+	}
+
+	enterprise := os.Getenv("GH_HOST")
+	if enterprise == "" {
+		return ""
+	}
+	if !strings.EqualFold(host, enterprise) && !strings.EqualFold((&url.URL{Host: host}).Hostname(), enterprise) {
+		return ""
+	}
+
+	for _, env := range []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token
+		}
+	}
+
+	return ""
 }
 
 func rateLimit(resp *http.Response) int {
@@ -161,7 +342,9 @@ func rateLimit(resp *http.Response) int {
 		}
 		return remain
 	}
-	return 0
+	// No header found (e.g. GitHub Enterprise Server without rate limiting),
+	// assume no limit
+	return -1
 }
 
 func isRateLimited(resp *http.Response) bool {
@@ -169,7 +352,8 @@ func isRateLimited(resp *http.Response) bool {
 }
 
 func isRateLimitClose(resp *http.Response) bool {
-	return rateLimit(resp) <= GithubLowRateLimit
+	remain := rateLimit(resp)
+	return remain >= 0 && remain <= GithubLowRateLimit
 }
 
 func handleRatelimit(resp *http.Response) error {
@@ -204,15 +388,7 @@ func handleRatelimit(resp *http.Response) error {
 	okdate := time.Unix(reset, 0)
 
 	if remain == 0 {
-		return fmt.Errorf("%w: rate limited by github; please retry after %s",
-			ErrGithubRateLimited,
-			okdate,
-		)
-	}
-	return fmt.Errorf("%w: remaining %d of %d; please retry after %s",
-		ErrGithubRateLimitClose,
-		remain,
-		limit,
-		okdate,
-	)
+		return &RateLimitError{Err: ErrGithubRateLimited, Source: "github", Limit: limit, Reset: okdate}
+	}
+	return &RateLimitError{Err: ErrGithubRateLimitClose, Source: "github", Remaining: remain, Limit: limit, Reset: okdate}
 }
@@ -0,0 +1,338 @@
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/devops-works/binenv/internal/httpcache"
+)
+
+// fakeReleases serves pages of tags (one slice per page) with ETags, and
+// answers 304 to matching conditional requests
+func fakeReleases(t *testing.T, pages *[][]string, requests, notModified *int) *httptest.Server {
+	t.Helper()
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 || page > len(*pages) {
+			w.Write([]byte("[]"))
+			return
+		}
+
+		releases := ghReleaseResponse{}
+		for _, tag := range (*pages)[page-1] {
+			releases = append(releases, ghRelease{TagName: tag})
+		}
+		body, _ := json.Marshal(releases)
+		etag := fmt.Sprintf(`W/"%x"`, body)
+
+		w.Header().Set("X-Ratelimit-Remaining", "59")
+		w.Header().Set("ETag", etag)
+		if page < len(*pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/releases?page=%d>; rel="next"`, ts.URL, page+1))
+		}
+
+		if r.Header.Get("If-None-Match") == etag {
+			*notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Write(body)
+	}))
+
+	return ts
+}
+
+func TestGithubRelease_Get_conditional(t *testing.T) {
+	pages := [][]string{
+		{"v1.2.0", "v1.1.0"},
+		{"v1.0.0", "v0.9.0"},
+		{"v0.8.0"},
+	}
+
+	requests, notModified := 0, 0
+	ts := fakeReleases(t, &pages, &requests, &notModified)
+	defer ts.Close()
+
+	t.Setenv("GITHUB_TOKEN", "")
+
+	validators, _ := httpcache.Open(filepath.Join(t.TempDir(), "etags.json"), 0640)
+	l := List{Type: "github-releases", URL: ts.URL + "/releases", Prefix: "v"}
+	lister := l.Factory(WithClient(ts.Client()), WithValidators(validators))
+
+	get := func() []string {
+		t.Helper()
+		requests, notModified = 0, 0
+		got, err := lister.Get(context.Background())
+		if err != nil {
+			t.Fatalf("GithubRelease.Get() error = %v", err)
+		}
+		return Versions(got)
+	}
+
+	want := []string{"1.2.0", "1.1.0", "1.0.0", "0.9.0", "0.8.0"}
+	if got := get(); !reflect.DeepEqual(got, want) || requests != 3 {
+		t.Errorf("GithubRelease.Get() = %v in %d requests, want %v in 3", got, requests, want)
+	}
+
+	// Unchanged: only the first page is requested
+	if got := get(); !reflect.DeepEqual(got, want) || requests != 1 || notModified != 1 {
+		t.Errorf("GithubRelease.Get() = %v in %d requests (%d not modified), want %v in 1 (1)", got, requests, notModified, want)
+	}
+
+	// A new release shifts every page
+	pages = [][]string{
+		{"v1.3.0", "v1.2.0"},
+		{"v1.1.0", "v1.0.0"},
+		{"v0.9.0", "v0.8.0"},
+	}
+	want = []string{"1.3.0", "1.2.0", "1.1.0", "1.0.0", "0.9.0", "0.8.0"}
+	if got := get(); !reflect.DeepEqual(got, want) || requests != 3 || notModified != 0 {
+		t.Errorf("GithubRelease.Get() = %v in %d requests (%d not modified), want %v in 3 (0)", got, requests, notModified, want)
+	}
+
+	// Without validators, every page is fetched
+	lister = l.Factory(WithClient(ts.Client()))
+	if got := get(); !reflect.DeepEqual(got, want) || requests != 3 || notModified != 0 {
+		t.Errorf("GithubRelease.Get() = %v in %d requests (%d not modified), want %v in 3 (0)", got, requests, notModified, want)
+	}
+}
+
+func TestGithubRelease_GetNew(t *testing.T) {
+	pages := [][]string{
+		{"v1.3.0", "v1.2.0"},
+		{"v1.1.0", "v1.0.0"},
+		{"v0.9.0", "v0.8.0"},
+	}
+
+	requests, notModified := 0, 0
+	ts := fakeReleases(t, &pages, &requests, &notModified)
+	defer ts.Close()
+
+	t.Setenv("GITHUB_TOKEN", "")
+
+	tests := []struct {
+		name     string
+		list     List
+		known    []string
+		want     []string
+		requests int
+	}{
+		{
+			name:     "stops at known version",
+			list:     List{Type: "github-releases", Prefix: "v"},
+			known:    []string{"1.1.0", "1.0.0", "0.9.0", "0.8.0"},
+			want:     []string{"1.3.0", "1.2.0", "1.1.0", "1.0.0"},
+			requests: 2,
+		},
+		{
+			name:     "nothing known",
+			list:     List{Type: "github-releases", Prefix: "v"},
+			want:     []string{"1.3.0", "1.2.0", "1.1.0", "1.0.0", "0.9.0", "0.8.0"},
+			requests: 3,
+		},
+		{
+			name:     "known versions are extracted",
+			list:     List{Type: "github-releases", VersionRegex: `^v(?P<version>1\..*)$`},
+			known:    []string{"1.3.0"},
+			want:     []string{"1.3.0", "1.2.0"},
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+
+			l := tt.list
+			l.URL = ts.URL + "/releases"
+			lister, ok := l.Factory(WithClient(ts.Client())).(IncrementalLister)
+			if !ok {
+				t.Fatalf("%T is not an IncrementalLister", l.Factory())
+			}
+
+			got, err := lister.GetNew(context.Background(), func(v string) bool {
+				return slices.Contains(tt.known, v)
+			})
+			if err != nil {
+				t.Fatalf("GithubRelease.GetNew() error = %v", err)
+			}
+			if !reflect.DeepEqual(Versions(got), tt.want) || requests != tt.requests {
+				t.Errorf("GithubRelease.GetNew() = %v in %d requests, want %v in %d", Versions(got), requests, tt.want, tt.requests)
+			}
+		})
+	}
+}
+
+func TestGithubRelease_Get_rateLimited(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "60")
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		http.Error(w, `{"message":"API rate limit exceeded"}`, http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	t.Setenv("GITHUB_TOKEN", "")
+
+	l := List{Type: "github-releases", URL: ts.URL + "/releases"}
+	_, err := l.Factory(WithClient(ts.Client())).Get(context.Background())
+	if !errors.Is(err, ErrGithubRateLimited) {
+		t.Fatalf("GithubRelease.Get() error = %v, want %v", err, ErrGithubRateLimited)
+	}
+
+	rle := &RateLimitError{}
+	if !errors.As(err, &rle) {
+		t.Fatalf("GithubRelease.Get() error = %T, want *RateLimitError", err)
+	}
+	if !rle.Reset.Equal(reset) || rle.Limit != 60 || rle.Remaining != 0 {
+		t.Errorf("RateLimitError = %+v, want reset %s, limit 60", rle, reset)
+	}
+}
+
+func TestGithubRelease_Get_rateLimitClose(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "60")
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Ratelimit-Remaining", "3")
+			w.Header().Set("Link", fmt.Sprintf(`<%s/releases?page=2>; rel="next"`, ts.URL))
+			w.Write([]byte(`[{"tag_name":"v1.1.0"}]`))
+			return
+		}
+		w.Header().Set("X-Ratelimit-Remaining", "2")
+		w.Write([]byte(`[{"tag_name":"v1.0.0"}]`))
+	}))
+	defer ts.Close()
+
+	t.Setenv("GITHUB_TOKEN", "")
+
+	l := List{Type: "github-releases", URL: ts.URL + "/releases", Prefix: "v"}
+	got, err := l.Factory(WithClient(ts.Client())).Get(context.Background())
+
+	rle := &RateLimitError{}
+	if !errors.As(err, &rle) || rle.Exhausted() {
+		t.Fatalf("GithubRelease.Get() error = %v, want %v", err, ErrGithubRateLimitClose)
+	}
+	if rle.Remaining != 2 {
+		t.Errorf("RateLimitError = %+v, want 2 remaining", rle)
+	}
+	// Pages were served, so releases are returned
+	if want := []string{"1.1.0", "1.0.0"}; !reflect.DeepEqual(Versions(got), want) {
+		t.Errorf("GithubRelease.Get() = %v, want %v", Versions(got), want)
+	}
+}
+
+func TestGithubRelease_Get_query(t *testing.T) {
+	pages := [][]string{{"v1.1.0"}, {"v1.0.0"}}
+	requests, notModified := 0, 0
+	ts := fakeReleases(t, &pages, &requests, &notModified)
+	defer ts.Close()
+
+	t.Setenv("GITHUB_TOKEN", "")
+
+	l := List{Type: "github-releases", URL: ts.URL + "/releases?per_page=1", Prefix: "v"}
+	got, err := l.Factory(WithClient(ts.Client())).Get(context.Background())
+	if err != nil {
+		t.Fatalf("GithubRelease.Get() error = %v", err)
+	}
+
+	want := []string{"1.1.0", "1.0.0"}
+	if !reflect.DeepEqual(Versions(got), want) {
+		t.Errorf("GithubRelease.Get() = %v, want %v", Versions(got), want)
+	}
+}
+
+func TestGithubRelease_Get_enterprise(t *testing.T) {
+	// GitHub Enterprise Server does not send rate limit headers when rate
+	// limiting is disabled
+	authorization := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/owner/tool/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte(`[{"tag_name": "v1.1.0"}, {"tag_name": "v1.0.0"}]`))
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+
+	tests := []struct {
+		name   string
+		ghHost string
+		want   string
+	}{
+		{name: "no GH_HOST"},
+		{name: "other GH_HOST", ghHost: "github.example.org"},
+		{name: "GH_HOST with port", ghHost: u.Host, want: "token secret"},
+		{name: "GH_HOST", ghHost: u.Hostname(), want: "token secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GH_HOST", tt.ghHost)
+			t.Setenv("GH_ENTERPRISE_TOKEN", "secret")
+			t.Setenv("GITHUB_ENTERPRISE_TOKEN", "")
+
+			l := List{Type: "github-releases", URL: ts.URL + "/owner/tool", Prefix: "v"}
+			got, err := l.Factory(WithClient(ts.Client())).Get(context.Background())
+			if err != nil {
+				t.Fatalf("GithubRelease.Get() error = %v", err)
+			}
+
+			want := []string{"1.1.0", "1.0.0"}
+			if !reflect.DeepEqual(Versions(got), want) {
+				t.Errorf("GithubRelease.Get() = %v, want %v", Versions(got), want)
+			}
+			if authorization != tt.want {
+				t.Errorf("GithubRelease.Get() sent Authorization %q, want %q", authorization, tt.want)
+			}
+		})
+	}
+}
+
+func Test_githubReleasesURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/owner/repo", "https://api.github.com/repos/owner/repo/releases"},
+		{"https://github.com/owner/repo.git", "https://api.github.com/repos/owner/repo/releases"},
+		{"https://github.com/owner/repo/releases", "https://api.github.com/repos/owner/repo/releases"},
+		{"https://api.github.com/repos/owner/repo/releases", "https://api.github.com/repos/owner/repo/releases"},
+		{"https://ghe.example.org/owner/repo/", "https://ghe.example.org/api/v3/repos/owner/repo/releases"},
+		{"https://ghe.example.org/api/v3/repos/owner/repo/releases", "https://ghe.example.org/api/v3/repos/owner/repo/releases"},
+		{"http://127.0.0.1:8080/releases", "http://127.0.0.1:8080/releases"},
+	}
+	for _, tt := range tests {
+		got, err := githubReleasesURL(tt.url)
+		if err != nil {
+			t.Errorf("githubReleasesURL(%q) error = %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("githubReleasesURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -14,6 +15,9 @@ import (
 	"time"
 
 	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/auth"
+	"github.com/devops-works/binenv/internal/httpcache"
 )
 
 var (
@@ -27,9 +31,27 @@ var (
 	GitlabLowRateLimit = 4
 )
 
+// gitlabHost is the gitlab.com host
+const gitlabHost = "gitlab.com"
+
 type glReleaseResponse []struct {
-	TagName string `json:"tag_name"`
-	Name    string `json:"name"`
+	TagName    string    `json:"tag_name"`
+	Name       string    `json:"name"`
+	ReleasedAt time.Time `json:"released_at"`
+	Links      struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Links []struct {
+			Name string `json:"name"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+// glPage is what is kept in the validators cache for a page of releases
+type glPage struct {
+	Releases glReleaseResponse `json:"releases"`
+	Next     int               `json:"next"`
 }
 
 // GitlabRelease contains what is required to get a list of release from Gitlab
@@ -38,70 +60,114 @@ type GitlabRelease struct {
 	prefix      string
 	exclude     string
 	versionFrom string
-	tokenEnv    string
+	auth        auth.Auth
+	credentials *auth.Resolver
+	client      *http.Client
+	validators  *httpcache.Cache
 }
 
 // Get returns a list of available versions
-func (g GitlabRelease) Get(ctx context.Context) ([]string, error) {
-	// logger := zerolog.Ctx(ctx).With().Str("func", "GitlabRelease.Get").Logger()
+// Pages are requested conditionally like for GithubRelease.
+func (g GitlabRelease) Get(ctx context.Context) ([]Release, error) {
+	return g.get(ctx, nil)
+}
+
+// GetNew returns available versions, stopping at the first page with a
+// known version
+func (g GitlabRelease) GetNew(ctx context.Context, known func(string) bool) ([]Release, error) {
+	return g.get(ctx, known)
+}
+
+func (g GitlabRelease) get(ctx context.Context, known func(string) bool) ([]Release, error) {
+	// logger := zerolog.Ctx(ctx).With().Str("func", "GitlabRelease.get").Logger()
+
+	api, err := gitlabReleasesURL(g.url)
+	if err != nil {
+		return nil, err
+	}
+	g.url = api
 
 	var (
-		next     = 1
-		versions []string
-		v        []string
-		err      error
+		next      = 1
+		versions  []Release
+		v         []Release
+		unchanged bool
+		// Pages served close to the rate limit are valid, so the error is
+		// returned along with versions
+		closeErr error
 	)
 
 	for next > 0 {
-		v, next, err = g.doGet(ctx, next)
-		if err != nil {
+		v, next, unchanged, err = g.doGet(ctx, next, unchanged)
+		if rateLimitClose(err) {
+			closeErr = err
+		} else if err != nil {
 			return nil, err
 		}
 		versions = append(versions, v...)
+		if anyKnown(v, known) {
+			break
+		}
 	}
 
-	return versions, err
+	return versions, closeErr
 }
 
-func (g GitlabRelease) doGet(ctx context.Context, page int) ([]string, int, error) {
+func (g GitlabRelease) doGet(ctx context.Context, page int, unchanged bool) ([]Release, int, bool, error) {
 	logger := zerolog.Ctx(ctx).With().Str("func", "GitlabRelease.doGet").Logger()
 
 	next := 0
-	client := &http.Client{}
+	pageURL, err := setQuery(g.url, url.Values{"page": {strconv.Itoa(page)}})
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if unchanged {
+		if e, ok := g.validators.Get(pageURL); ok {
+			versions, next, err := g.cachedPage(ctx, e)
+			return versions, next, err == nil, err
+		}
+	}
 
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s?page=%d", g.url, page), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
 	}
+	cached, conditional := g.validators.Prepare(req)
 
-	if token := os.Getenv("GITLAB_TOKEN"); token != "" {
-		req.Header.Set("Authorization", "token "+token)
+	authorized, err := g.credentials.Authorize(req, g.auth)
+	if err != nil {
+		return nil, 0, false, err
 	}
 
-	if g.tokenEnv != "" {
-		fmt.Println("tokenEnv set to", g.tokenEnv)
-		token := os.Getenv(g.tokenEnv)
-		if token == "" {
-			return nil, 0, fmt.Errorf("token env var %s is not defined; did you export it ?", g.tokenEnv)
-		}
+	// GITLAB_TOKEN is only sent to gitlab.com; tokens for other instances
+	// are set in the credentials file
+	if token := os.Getenv("GITLAB_TOKEN"); token != "" && !authorized && req.URL.Host == gitlabHost {
 		req.Header.Set("PRIVATE-TOKEN", token)
 	}
 
-	resp, err := client.Do(req)
+	resp, err := g.client.Do(req)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		resp.Body.Close()
+		logger.Debug().Msgf("page %d of %s not modified", page, g.url)
+		versions, next, err := g.cachedPage(ctx, cached)
+		return versions, next, err == nil && (page == 1 || unchanged), err
 	}
 
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, false, err
 	}
 
 	// // Check if we are already rate limited
 	if isGitlabRateLimited(resp) {
-		return nil, 0, handleGitlabRatelimit(resp)
+		return nil, 0, false, handleGitlabRatelimit(resp)
 	}
 
 	// fmt.Println(string(body))
@@ -109,20 +175,68 @@ func (g GitlabRelease) doGet(ctx context.Context, page int) ([]string, int, erro
 	err = json.Unmarshal([]byte(body), &gr)
 	if err != nil {
 		logger.Error().Err(err).Msgf("error unmarshalling gitlab response for %s", g.url)
-		return nil, 0, err
+		return nil, 0, false, err
 	}
 	// fmt.Printf("%v\n", gr)
 
+	versions, err := g.releases(ctx, gr)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if len(resp.Header["Link"]) > 0 && strings.Contains(resp.Header["Link"][0], "rel=\"next\"") {
+		re := regexp.MustCompile(`page=(\d*)>; rel="next"`)
+		match := re.FindStringSubmatch(resp.Header["Link"][0])
+		next, err = strconv.Atoi(match[1])
+
+		if err != nil {
+			return nil, 0, false, err
+		}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		err = g.validators.Set(resp, glPage{Releases: gr, Next: next})
+		if err != nil {
+			logger.Warn().Err(err).Msgf("unable to cache validators for %s", pageURL)
+		}
+	}
+
+	if isGitlabRateLimitClose(resp) {
+		return versions, next, false, handleGitlabRatelimit(resp)
+	}
+	return versions, next, false, nil
+}
+
+// cachedPage returns versions and next page from a validators cache entry
+func (g GitlabRelease) cachedPage(ctx context.Context, e httpcache.Entry) ([]Release, int, error) {
+	p := glPage{}
+	if err := json.Unmarshal(e.Data, &p); err != nil {
+		return nil, 0, fmt.Errorf("invalid cached page for %s: %w", g.url, err)
+	}
+
+	versions, err := g.releases(ctx, p.Releases)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return versions, p.Next, nil
+}
+
+// releases extracts releases according to versionFrom, exclude and prefix
+func (g GitlabRelease) releases(ctx context.Context, gr glReleaseResponse) ([]Release, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "GitlabRelease.releases").Logger()
+
 	var re *regexp.Regexp
 	if g.exclude != "" {
+		var err error
 		re, err = regexp.Compile(g.exclude)
 		if err != nil {
 			logger.Error().Err(err).Msgf("error compiling regular expression %q", g.exclude)
-			return nil, 0, err
+			return nil, err
 		}
 	}
 
-	versions := []string{}
+	releases := []Release{}
 
 	for _, v := range gr {
 		sv := v.TagName
@@ -136,31 +250,48 @@ func (g GitlabRelease) doGet(ctx context.Context, page int) ([]string, int, erro
 			continue
 		}
 
-		if g.prefix == "" {
-			versions = append(versions, sv)
-			continue
+		if g.prefix != "" {
+			if !strings.HasPrefix(sv, g.prefix) {
+				continue
+			}
+			sv = strings.TrimPrefix(sv, g.prefix)
 		}
 
-		if strings.HasPrefix(sv, g.prefix) {
-			cleanv := strings.TrimPrefix(sv, g.prefix)
-			versions = append(versions, cleanv)
+		r := Release{
+			Version:   sv,
+			Published: v.ReleasedAt,
+			URL:       v.Links.Self,
 		}
+		for _, a := range v.Assets.Links {
+			r.Assets = append(r.Assets, a.Name)
+		}
+		releases = append(releases, r)
 	}
 
-	if len(resp.Header["Link"]) > 0 && strings.Contains(resp.Header["Link"][0], "rel=\"next\"") {
-		re := regexp.MustCompile(`page=(\d*)>; rel="next"`)
-		match := re.FindStringSubmatch(resp.Header["Link"][0])
-		next, err = strconv.Atoi(match[1])
+	return releases, nil
+}
 
-		if err != nil {
-			return nil, 0, err
-		}
+// gitlabReleasesURL returns the releases API endpoint for u, which can be
+// either the API endpoint itself (with a numeric or an encoded project ID) or
+// the project URL (e.g. https://gitlab.example.org/group/subgroup/project)
+func gitlabReleasesURL(u string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(u, "/"))
+	if err != nil {
+		return "", err
 	}
 
-	if isGitlabRateLimitClose(resp) {
-		return versions, next, handleGitlabRatelimit(resp)
+	if strings.HasPrefix(parsed.Path, "/api/v4/") {
+		return parsed.String(), nil
 	}
-	return versions, next, nil
+
+	project := strings.TrimSuffix(strings.Trim(parsed.Path, "/"), "/-/releases")
+	project = strings.TrimSuffix(project, ".git")
+	if !strings.Contains(project, "/") {
+		return "", fmt.Errorf("invalid gitlab project URL %q: expecting <base>/<namespace>/<project>", u)
+	}
+
+	// Projects are looked up by their URL encoded path
+	return fmt.Sprintf("%s://%s/api/v4/projects/%s/releases", parsed.Scheme, parsed.Host, url.PathEscape(project)), nil
 }
 
 func gitlabRateLimit(resp *http.Response) int {
@@ -217,15 +348,7 @@ func handleGitlabRatelimit(resp *http.Response) error {
 	okdate := time.Unix(reset, 0)
 
 	if remain == 0 {
-		return fmt.Errorf("%w: rate limited by gitlab; please retry after %s",
-			ErrGitlabRateLimited,
-			okdate,
-		)
-	}
-	return fmt.Errorf("%w: remaining %d of %d; please retry after %s",
-		ErrGitlabRateLimitClose,
-		remain,
-		limit,
-		okdate,
-	)
+		return &RateLimitError{Err: ErrGitlabRateLimited, Source: "gitlab", Limit: limit, Reset: okdate}
+	}
+	return &RateLimitError{Err: ErrGitlabRateLimitClose, Source: "gitlab", Remaining: remain, Limit: limit, Reset: okdate}
 }
@@ -0,0 +1,153 @@
+package list
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/devops-works/binenv/internal/auth"
+)
+
+// fakeGitlab serves releases for the group/sub/project project, looked up by
+// its encoded path, and requires token when set
+func fakeGitlab(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fsub%2Fproject/releases" {
+			http.Error(w, `{"message":"404 Project Not Found"}`, http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("page") == "" {
+			http.Error(w, `{"message":"400 Bad request"}`, http.StatusBadRequest)
+			return
+		}
+		if token != "" && r.Header.Get("PRIVATE-TOKEN") != token && r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, `{"message":"404 Project Not Found"}`, http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"tag_name": "v1.1.0", "assets": map[string]interface{}{"links": []map[string]string{{"name": "project-linux-amd64"}}}},
+			{"tag_name": "v1.0.0"},
+		})
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestGitlabRelease_Get(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name        string
+		list        List
+		token       string
+		credentials string
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name: "project URL",
+			list: List{Type: "gitlab-releases", URL: "/group/sub/project", Prefix: "v"},
+			want: []string{"1.1.0", "1.0.0"},
+		},
+		{
+			name: "API URL",
+			list: List{Type: "gitlab-releases", URL: "/api/v4/projects/group%2Fsub%2Fproject/releases", Prefix: "v"},
+			want: []string{"1.1.0", "1.0.0"},
+		},
+		{
+			name: "API URL with query",
+			list: List{Type: "gitlab-releases", URL: "/api/v4/projects/group%2Fsub%2Fproject/releases?order_by=released_at", Prefix: "v"},
+			want: []string{"1.1.0", "1.0.0"},
+		},
+		{
+			name:  "token",
+			list:  List{Type: "gitlab-releases", URL: "/group/sub/project/-/releases", Prefix: "v", TokenEnv: "BINENV_TEST_GITLAB_TOKEN"},
+			token: "s3cr3t",
+			want:  []string{"1.1.0", "1.0.0"},
+		},
+		{
+			name:        "credentials file",
+			list:        List{Type: "gitlab-releases", URL: "/group/sub/project", Prefix: "v"},
+			token:       "s3cr3t",
+			credentials: "hosts:\n  127.0.0.1:\n    token_env: BINENV_TEST_GITLAB_TOKEN\n",
+			want:        []string{"1.1.0", "1.0.0"},
+		},
+		{
+			name:    "unauthorized",
+			list:    List{Type: "gitlab-releases", URL: "/group/sub/project"},
+			token:   "s3cr3t",
+			wantErr: true,
+		},
+		{
+			name:    "invalid project URL",
+			list:    List{Type: "gitlab-releases", URL: "/project"},
+			wantErr: true,
+		},
+	}
+
+	t.Setenv("BINENV_TEST_GITLAB_TOKEN", "s3cr3t")
+	t.Setenv("GITLAB_TOKEN", "s3cr3t")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := fakeGitlab(t, tt.token)
+			defer ts.Close()
+
+			file := filepath.Join(dir, "missing.yaml")
+			if tt.credentials != "" {
+				file = filepath.Join(dir, "credentials.yaml")
+				if err := os.WriteFile(file, []byte(tt.credentials), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r, err := auth.NewResolver(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l := tt.list
+			l.URL = ts.URL + l.URL
+
+			got, err := l.Factory(WithClient(ts.Client()), WithCredentials(r)).Get(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GitlabRelease.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(Versions(got), tt.want) {
+				t.Errorf("GitlabRelease.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_gitlabReleasesURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"https://gitlab.com/group/project", "https://gitlab.com/api/v4/projects/group%2Fproject/releases", false},
+		{"https://gitlab.example.org/group/sub/project.git", "https://gitlab.example.org/api/v4/projects/group%2Fsub%2Fproject/releases", false},
+		{"https://gitlab.example.org/group/project/-/releases", "https://gitlab.example.org/api/v4/projects/group%2Fproject/releases", false},
+		{"https://gitlab.example.org/api/v4/projects/42/releases", "https://gitlab.example.org/api/v4/projects/42/releases", false},
+		{"https://gitlab.example.org/project", "", true},
+	}
+	for _, tt := range tests {
+		got, err := gitlabReleasesURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("gitlabReleasesURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("gitlabReleasesURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package list
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/auth"
+)
+
+// defaultGoProxy is used when GOPROXY is not set, like the go command does
+const defaultGoProxy = "https://proxy.golang.org,direct"
+
+// errModuleNotFound is returned by proxies not knowing a module
+var errModuleNotFound = errors.New("module not found")
+
+// GoProxy contains what is required to get a list of versions of a Go module
+// from a module proxy
+// Proxies are read from GOPROXY, and modules matching GONOPROXY (or
+// GOPRIVATE) patterns are not looked up from proxies, like the go command
+// does.
+type GoProxy struct {
+	module      string
+	prefix      string
+	exclude     string
+	auth        auth.Auth
+	credentials *auth.Resolver
+	client      *http.Client
+}
+
+// goProxy is a GOPROXY entry
+type goProxy struct {
+	url string
+	// fallback is set when the next proxy is tried on any error, and not
+	// only when the module is not found
+	fallback bool
+}
+
+// Get returns a list of available versions
+func (g GoProxy) Get(ctx context.Context) ([]Release, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "GoProxy.Get").Logger()
+
+	if g.module == "" {
+		return nil, fmt.Errorf("goproxy lister requires a module path in url")
+	}
+
+	escaped, err := escapeModulePath(g.module)
+	if err != nil {
+		return nil, err
+	}
+
+	proxies := goProxies(os.Getenv("GOPROXY"))
+	if matchPrefixPatterns(goNoProxy(), g.module) {
+		logger.Debug().Msgf("module %s matches GONOPROXY patterns", g.module)
+		proxies = []goProxy{{url: "direct"}}
+	}
+
+	var body []byte
+	for _, p := range proxies {
+		switch p.url {
+		case "off":
+			return nil, fmt.Errorf("module lookup disabled by GOPROXY=off")
+		case "direct":
+			// Tell why previous proxies failed, if any
+			if err == nil {
+				err = fmt.Errorf("module %s can only be listed directly; use a git-tags list instead", g.module)
+			}
+			return nil, err
+		}
+
+		body, err = g.doGet(ctx, strings.TrimSuffix(p.url, "/")+"/"+escaped+"/@v/list")
+		if err == nil {
+			break
+		}
+		if !p.fallback && !errors.Is(err, errModuleNotFound) {
+			return nil, err
+		}
+		logger.Debug().Err(err).Msg("trying next proxy")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var re *regexp.Regexp
+	if g.exclude != "" {
+		re, err = regexp.Compile(g.exclude)
+		if err != nil {
+			logger.Error().Err(err).Msgf("error compiling regular expression %q", g.exclude)
+			return nil, err
+		}
+	}
+
+	versions := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		// Major versions without go.mod are listed with +incompatible, which
+		// is not part of release tags
+		sv := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), "+incompatible")
+		if sv == "" {
+			continue
+		}
+
+		if re != nil && re.Match([]byte(sv)) {
+			logger.Debug().Msgf("skipping version %q excluded by exclude regexp %q", sv, g.exclude)
+			continue
+		}
+
+		if g.prefix != "" {
+			if !strings.HasPrefix(sv, g.prefix) {
+				continue
+			}
+			sv = strings.TrimPrefix(sv, g.prefix)
+		}
+
+		versions = append(versions, sv)
+	}
+
+	return newReleases(versions), scanner.Err()
+}
+
+func (g GoProxy) doGet(ctx context.Context, u string) ([]byte, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "GoProxy.doGet").Logger()
+
+	logger.Debug().Msgf("fetching versions from %s", u)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = g.credentials.Authorize(req, g.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("unable to list versions from %s: %w", u, errModuleNotFound)
+	}
+
+	return nil, fmt.Errorf("unable to list versions from %s: %s", u, resp.Status)
+}
+
+// goProxies returns proxies in a GOPROXY value
+// Proxies separated by commas are tried when the previous one does not know
+// the module; proxies separated by pipes are tried on any error.
+func goProxies(env string) []goProxy {
+	if strings.TrimSpace(env) == "" {
+		env = defaultGoProxy
+	}
+
+	proxies := []goProxy{}
+	for env != "" {
+		i := strings.IndexAny(env, ",|")
+		if i < 0 {
+			i = len(env)
+		}
+
+		p := goProxy{url: strings.TrimSpace(env[:i])}
+		if i < len(env) {
+			p.fallback = env[i] == '|'
+			i++
+		}
+		env = env[i:]
+
+		if p.url != "" {
+			proxies = append(proxies, p)
+		}
+	}
+
+	return proxies
+}
+
+// goNoProxy returns module path patterns not looked up from proxies
+// GONOPROXY defaults to GOPRIVATE.
+func goNoProxy() string {
+	if v := os.Getenv("GONOPROXY"); v != "" {
+		return v
+	}
+
+	return os.Getenv("GOPRIVATE")
+}
+
+// matchPrefixPatterns returns true if a leading part of module matches one
+// of the comma separated glob patterns
+func matchPrefixPatterns(patterns, module string) bool {
+	elems := strings.Split(module, "/")
+	for _, p := range strings.Split(patterns, ",") {
+		p = strings.TrimSuffix(strings.TrimSpace(p), "/")
+		if p == "" {
+			continue
+		}
+
+		n := strings.Count(p, "/") + 1
+		if n > len(elems) {
+			continue
+		}
+
+		if ok, _ := path.Match(p, strings.Join(elems[:n], "/")); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// escapeModulePath returns module path as used in proxy URLs
+// Proxies may serve files from case insensitive file systems, so upper case
+// letters are replaced by an exclamation mark followed by the lower case
+// letter (e.g. github.com/!burnt!sushi/toml).
+func escapeModulePath(module string) (string, error) {
+	var b strings.Builder
+	for _, r := range module {
+		switch {
+		case r == '!':
+			return "", fmt.Errorf("invalid module path %q", module)
+		case 'A' <= r && r <= 'Z':
+			b.WriteByte('!')
+			b.WriteRune(r + 'a' - 'A')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,151 @@
+package list
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGoProxy_Get(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/github.com/!org/tool/@v/list", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v1.0.0\nv1.1.0-rc.1\nv1.1.0\n\nv2.0.0+incompatible\n"))
+	})
+	mux.HandleFunc("/broken/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/gone/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	all := []string{"1.0.0", "1.1.0-rc.1", "1.1.0", "2.0.0"}
+
+	tests := []struct {
+		name      string
+		list      List
+		goproxy   string
+		gonoproxy string
+		goprivate string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:    "proxy",
+			list:    List{URL: "github.com/Org/tool", Prefix: "v"},
+			goproxy: ts.URL,
+			want:    all,
+		},
+		{
+			name:    "exclude",
+			list:    List{URL: "github.com/Org/tool", Prefix: "v", Exclude: "-rc"},
+			goproxy: ts.URL + "/",
+			want:    []string{"1.0.0", "1.1.0", "2.0.0"},
+		},
+		{
+			name:    "not found",
+			list:    List{URL: "github.com/org/tool", Prefix: "v"},
+			goproxy: ts.URL,
+			wantErr: true,
+		},
+		{
+			name:    "not found falls back",
+			list:    List{URL: "github.com/Org/tool", Prefix: "v"},
+			goproxy: ts.URL + "/gone," + ts.URL,
+			want:    all,
+		},
+		{
+			name:    "error does not fall back",
+			list:    List{URL: "github.com/Org/tool", Prefix: "v"},
+			goproxy: ts.URL + "/broken," + ts.URL,
+			wantErr: true,
+		},
+		{
+			name:    "error falls back",
+			list:    List{URL: "github.com/Org/tool", Prefix: "v"},
+			goproxy: ts.URL + "/broken|" + ts.URL,
+			want:    all,
+		},
+		{
+			name:    "direct",
+			list:    List{URL: "github.com/Org/tool", Prefix: "v"},
+			goproxy: "direct",
+			wantErr: true,
+		},
+		{
+			name:    "off",
+			list:    List{URL: "github.com/Org/tool", Prefix: "v"},
+			goproxy: "off",
+			wantErr: true,
+		},
+		{
+			name:      "gonoproxy",
+			list:      List{URL: "github.com/Org/tool", Prefix: "v"},
+			goproxy:   ts.URL,
+			gonoproxy: "example.com,github.com/Org",
+			wantErr:   true,
+		},
+		{
+			name:      "goprivate",
+			list:      List{URL: "github.com/Org/tool", Prefix: "v"},
+			goproxy:   ts.URL,
+			goprivate: "github.com/*",
+			wantErr:   true,
+		},
+		{
+			name:    "no module",
+			list:    List{Prefix: "v"},
+			goproxy: ts.URL,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOPROXY", tt.goproxy)
+			t.Setenv("GONOPROXY", tt.gonoproxy)
+			t.Setenv("GOPRIVATE", tt.goprivate)
+
+			l := tt.list
+			l.Type = "goproxy"
+
+			got, err := l.Factory(WithClient(ts.Client())).Get(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GoProxy.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(Versions(got), tt.want) {
+				t.Errorf("GoProxy.Get() = %v, want %v", Versions(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_escapeModulePath(t *testing.T) {
+	tests := []struct {
+		module  string
+		want    string
+		wantErr bool
+	}{
+		{module: "github.com/BurntSushi/toml", want: "github.com/!burnt!sushi/toml"},
+		{module: "sigs.k8s.io/kustomize/kustomize/v5", want: "sigs.k8s.io/kustomize/kustomize/v5"},
+		{module: "github.com/!org/tool", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.module, func(t *testing.T) {
+			got, err := escapeModulePath(tt.module)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("escapeModulePath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("escapeModulePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package list
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/auth"
+)
+
+// HTTPIndex contains what is required to extract versions from any HTTP
+// document (directory listings, JSON indexes, plain text files...)
+type HTTPIndex struct {
+	url         string
+	regex       string
+	jsonPath    string
+	prefix      string
+	exclude     string
+	auth        auth.Auth
+	credentials *auth.Resolver
+	client      *http.Client
+}
+
+// Get returns a list of available versions
+func (h HTTPIndex) Get(ctx context.Context) ([]Release, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "HTTPIndex.Get").Logger()
+
+	if h.regex == "" && h.jsonPath == "" {
+		return nil, fmt.Errorf("http-index lister for %s requires regex or jsonpath", h.url)
+	}
+
+	var (
+		extract *regexp.Regexp
+		err     error
+	)
+	if h.regex != "" {
+		extract, err = regexp.Compile(h.regex)
+		if err != nil {
+			logger.Error().Err(err).Msgf("error compiling regular expression %q", h.regex)
+			return nil, err
+		}
+	}
+
+	logger.Debug().Msgf("fetching versions from %s", h.url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = h.credentials.Authorize(req, h.auth)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch index %s: %s", h.url, resp.Status)
+	}
+
+	// With a JSONPath, the regex (if any) extracts versions from each matched
+	// value; otherwise it applies to the whole document
+	candidates := []string{string(body)}
+	if h.jsonPath != "" {
+		candidates, err = jsonPath(body, h.jsonPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if extract != nil {
+		candidates = extractAll(extract, candidates)
+	}
+
+	var re *regexp.Regexp
+	if h.exclude != "" {
+		re, err = regexp.Compile(h.exclude)
+		if err != nil {
+			logger.Error().Err(err).Msgf("error compiling regular expression %q", h.exclude)
+			return nil, err
+		}
+	}
+
+	versions := []string{}
+	seen := make(map[string]bool)
+
+	for _, sv := range candidates {
+		sv = strings.TrimSpace(sv)
+		if sv == "" || seen[sv] {
+			continue
+		}
+		seen[sv] = true
+
+		if re != nil && re.Match([]byte(sv)) {
+			logger.Debug().Msgf("skipping version %q excluded by exclude regexp %q", sv, h.exclude)
+			continue
+		}
+
+		if h.prefix == "" {
+			versions = append(versions, sv)
+			continue
+		}
+
+		if strings.HasPrefix(sv, h.prefix) {
+			cleanv := strings.TrimPrefix(sv, h.prefix)
+			versions = append(versions, cleanv)
+		}
+	}
+
+	return newReleases(versions), nil
+}
+
+// extractAll returns all matches of re in candidates (see versionGroup)
+func extractAll(re *regexp.Regexp, candidates []string) []string {
+	group := versionGroup(re)
+
+	res := []string{}
+	for _, c := range candidates {
+		for _, m := range re.FindAllStringSubmatch(c, -1) {
+			res = append(res, m[group])
+		}
+	}
+
+	return res
+}
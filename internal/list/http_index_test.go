@@ -0,0 +1,155 @@
+package list
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const (
+	directoryListing = `<html><body><h1>Index of /releases</h1>
+<a href="../">../</a>
+<a href="tool-1.2.0.tar.gz">tool-1.2.0.tar.gz</a>
+<a href="tool-1.2.0.tar.gz.sha256">tool-1.2.0.tar.gz.sha256</a>
+<a href="tool-1.3.0-rc1.tar.gz">tool-1.3.0-rc1.tar.gz</a>
+<a href="tool-1.1.0.tar.gz">tool-1.1.0.tar.gz</a>
+</body></html>`
+
+	hashicorpIndex = `{
+  "name": "terraform",
+  "versions": {
+    "1.5.0": {"name": "terraform", "version": "1.5.0"},
+    "1.6.0-beta1": {"name": "terraform", "version": "1.6.0-beta1"},
+    "1.4.6": {"name": "terraform", "version": "1.4.6"}
+  }
+}`
+
+	releasesJSON = `[
+  {"tag_name": "v2.0.0", "assets": [{"name": "tool-linux"}]},
+  {"tag_name": "v1.9.1", "assets": []}
+]`
+)
+
+func TestHTTPIndex_Get(t *testing.T) {
+	mux := http.NewServeMux()
+	for path, content := range map[string]string{
+		"/releases/":   directoryListing,
+		"/stable.txt":  "v1.29.3\n",
+		"/index.json":  hashicorpIndex,
+		"/tags.json":   releasesJSON,
+		"/broken.json": "{",
+	} {
+		content := content
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		})
+	}
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		list    List
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "directory listing",
+			list: List{URL: "/releases/", Regex: `href="tool-([0-9][^"]*)\.tar\.gz"`},
+			want: []string{"1.2.0", "1.3.0-rc1", "1.1.0"},
+		},
+		{
+			name: "directory listing with exclude",
+			list: List{URL: "/releases/", Regex: `tool-(?P<version>[0-9][^"]*)\.tar\.gz"`, Exclude: "-rc"},
+			want: []string{"1.2.0", "1.1.0"},
+		},
+		{
+			name: "plain text with prefix",
+			list: List{URL: "/stable.txt", Regex: `v[0-9.]+`, Prefix: "v"},
+			want: []string{"1.29.3"},
+		},
+		{
+			name: "JSON keys",
+			list: List{URL: "/index.json", JSONPath: "$.versions.*~"},
+			want: []string{"1.4.6", "1.5.0", "1.6.0-beta1"},
+		},
+		{
+			name: "JSON values",
+			list: List{URL: "/index.json", JSONPath: "$..version", Exclude: "beta"},
+			want: []string{"1.4.6", "1.5.0"},
+		},
+		{
+			name: "JSON array with prefix",
+			list: List{URL: "/tags.json", JSONPath: ".[*].tag_name", Prefix: "v"},
+			want: []string{"2.0.0", "1.9.1"},
+		},
+		{
+			name: "JSONPath then regex",
+			list: List{URL: "/tags.json", JSONPath: "$[0].tag_name", Regex: `^v(\d+)\.`},
+			want: []string{"2"},
+		},
+		{
+			name:    "invalid JSON",
+			list:    List{URL: "/broken.json", JSONPath: "$.versions"},
+			wantErr: true,
+		},
+		{
+			name:    "no extraction",
+			list:    List{URL: "/stable.txt"},
+			wantErr: true,
+		},
+		{
+			name:    "not found",
+			list:    List{URL: "/missing", Regex: ".*"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := tt.list
+			l.Type = "http-index"
+			l.URL = ts.URL + l.URL
+
+			got, err := l.Factory(WithClient(ts.Client())).Get(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HTTPIndex.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(Versions(got), tt.want) {
+				t.Errorf("HTTPIndex.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_jsonPath(t *testing.T) {
+	doc := []byte(`{"a": {"b": [{"c": "x"}, {"c": "y", "d": 1.5}], "e": "z"}, "f.g": "dotted"}`)
+
+	tests := []struct {
+		expr    string
+		want    []string
+		wantErr bool
+	}{
+		{"$.a.e", []string{"z"}, false},
+		{"$.a.b[*].c", []string{"x", "y"}, false},
+		{"$.a.b[-1].c", []string{"y"}, false},
+		{"$.a.b[1].d", []string{"1.5"}, false},
+		{"$['f.g']", []string{"dotted"}, false},
+		{"$..c", []string{"x", "y"}, false},
+		{"$.a.*~", []string{"b", "e"}, false},
+		{"$.missing", []string{}, false},
+		{"$.a.b[", nil, true},
+		{"$.a.b[foo]", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := jsonPath(doc, tt.expr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("jsonPath(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("jsonPath(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
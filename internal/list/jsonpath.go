@@ -0,0 +1,195 @@
+package list
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonNode is a value matched by a JSONPath expression, along with the key
+// (or index) it was found at
+type jsonNode struct {
+	key   string
+	value interface{}
+}
+
+// jsonPath evaluates a JSONPath expression against doc and returns matched
+// scalar values as strings
+//
+// Supported syntax is a subset of JSONPath:
+//
+//	$               root (optional; jq-like paths starting with . are accepted)
+//	.name ['name']  child
+//	.* [*]          all children of objects or arrays
+//	[n]             array element (negative indexes count from the end)
+//	..name ..*      recursive descent
+//	~               keys of the matched values (e.g. $.versions.*~)
+func jsonPath(doc []byte, expr string) ([]string, error) {
+	var root interface{}
+
+	d := json.NewDecoder(bytes.NewReader(doc))
+	d.UseNumber()
+	if err := d.Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+
+	nodes := []jsonNode{{key: "$", value: root}}
+
+	p := strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	for p != "" {
+		var err error
+
+		switch {
+		case strings.HasPrefix(p, ".."):
+			p = p[2:]
+			nodes = descendants(nodes)
+			if strings.HasPrefix(p, "[") {
+				continue
+			}
+			var name string
+			name, p = cutName(p)
+			nodes = child(nodes, name)
+		case strings.HasPrefix(p, ".["):
+			// jq-like .[...]
+			p = p[1:]
+		case strings.HasPrefix(p, "."):
+			var name string
+			name, p = cutName(p[1:])
+			if name == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty name", expr)
+			}
+			nodes = child(nodes, name)
+		case strings.HasPrefix(p, "["):
+			end := strings.Index(p, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unclosed [", expr)
+			}
+			nodes, err = bracket(nodes, p[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+			}
+			p = p[end+1:]
+		case strings.HasPrefix(p, "~"):
+			keys := make([]jsonNode, 0, len(nodes))
+			for _, n := range nodes {
+				keys = append(keys, jsonNode{key: n.key, value: n.key})
+			}
+			nodes = keys
+			p = p[1:]
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", expr, p)
+		}
+	}
+
+	values := []string{}
+	for _, n := range nodes {
+		switch v := n.value.(type) {
+		case string:
+			values = append(values, v)
+		case json.Number:
+			values = append(values, v.String())
+		}
+	}
+
+	return values, nil
+}
+
+// cutName splits p after a member name
+func cutName(p string) (string, string) {
+	end := strings.IndexAny(p, ".[~")
+	if end < 0 {
+		return p, ""
+	}
+
+	return p[:end], p[end:]
+}
+
+// child returns name children of nodes; * matches all children
+func child(nodes []jsonNode, name string) []jsonNode {
+	res := []jsonNode{}
+	for _, n := range nodes {
+		switch v := n.value.(type) {
+		case map[string]interface{}:
+			if name == "*" {
+				res = append(res, members(v)...)
+				continue
+			}
+			if c, ok := v[name]; ok {
+				res = append(res, jsonNode{key: name, value: c})
+			}
+		case []interface{}:
+			if name == "*" {
+				for i, c := range v {
+					res = append(res, jsonNode{key: strconv.Itoa(i), value: c})
+				}
+			}
+		}
+	}
+
+	return res
+}
+
+// bracket applies a [...] selector to nodes
+func bracket(nodes []jsonNode, sel string) ([]jsonNode, error) {
+	sel = strings.TrimSpace(sel)
+
+	if sel == "*" {
+		return child(nodes, "*"), nil
+	}
+
+	if len(sel) >= 2 && (sel[0] == '\'' || sel[0] == '"') && sel[len(sel)-1] == sel[0] {
+		return child(nodes, sel[1:len(sel)-1]), nil
+	}
+
+	idx, err := strconv.Atoi(sel)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported selector [%s]", sel)
+	}
+
+	res := []jsonNode{}
+	for _, n := range nodes {
+		a, ok := n.value.([]interface{})
+		if !ok {
+			continue
+		}
+		i := idx
+		if i < 0 {
+			i += len(a)
+		}
+		if i >= 0 && i < len(a) {
+			res = append(res, jsonNode{key: strconv.Itoa(i), value: a[i]})
+		}
+	}
+
+	return res, nil
+}
+
+// descendants returns nodes and all their descendants, depth first
+func descendants(nodes []jsonNode) []jsonNode {
+	res := []jsonNode{}
+	for _, n := range nodes {
+		res = append(res, n)
+		res = append(res, descendants(child([]jsonNode{n}, "*"))...)
+	}
+
+	return res
+}
+
+// members returns object members sorted by key, so results are stable
+func members(m map[string]interface{}) []jsonNode {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	res := make([]jsonNode, 0, len(m))
+	for _, k := range keys {
+		res = append(res, jsonNode{key: k, value: m[k]})
+	}
+
+	return res
+}
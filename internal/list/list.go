@@ -2,11 +2,89 @@ package list
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/devops-works/binenv/internal/auth"
+	"github.com/devops-works/binenv/internal/httpcache"
 )
 
-// Lister should return a list of available release versions
+// Lister should return a list of available releases
 type Lister interface {
-	Get(ctx context.Context) ([]string, error)
+	Get(ctx context.Context) ([]Release, error)
+}
+
+// IncrementalLister is implemented by listers able to stop listing once they
+// reach releases already known
+// Releases being listed most recent first, GetNew returns at least new
+// releases, but can return known ones too.
+type IncrementalLister interface {
+	GetNew(ctx context.Context, known func(version string) bool) ([]Release, error)
+}
+
+// anyKnown returns true if one of releases is known
+func anyKnown(releases []Release, known func(string) bool) bool {
+	if known == nil {
+		return false
+	}
+	for _, r := range releases {
+		if known(r.Version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setQuery returns u with query parameters in values set
+// Parameters already in u are kept, so API URLs given by users can carry
+// their own.
+func setQuery(u string, values url.Values) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	q := parsed.Query()
+	for k, v := range values {
+		q[k] = v
+	}
+	parsed.RawQuery = q.Encode()
+
+	return parsed.String(), nil
+}
+
+// Release describes an available version
+// Only Version is always set; other fields depend on what the source
+// provides.
+type Release struct {
+	Version    string    `json:"version"`
+	Prerelease bool      `json:"prerelease,omitempty"`
+	Draft      bool      `json:"draft,omitempty"`
+	Published  time.Time `json:"published,omitzero"`
+	URL        string    `json:"url,omitempty"`
+	Assets     []string  `json:"assets,omitempty"`
+}
+
+// Versions returns versions of releases
+func Versions(releases []Release) []string {
+	versions := make([]string, 0, len(releases))
+	for _, r := range releases {
+		versions = append(versions, r.Version)
+	}
+
+	return versions
+}
+
+// newReleases returns releases for sources that only provide versions
+func newReleases(versions []string) []Release {
+	releases := make([]Release, 0, len(versions))
+	for _, v := range versions {
+		releases = append(releases, Release{Version: v})
+	}
+
+	return releases
 }
 
 // List contains list definition
@@ -15,28 +93,158 @@ type List struct {
 	Prefix      string `yaml:"prefix"`
 	Exclude     string `yaml:"exclude"` // exclude versions containing this regex
 	VersionFrom string `yaml:"version_from"`
-	URL         string `yaml:"url"`
-	TokenEnv    string `yaml:"token_env"`
-	Versions    []string
+	// URL is the module path for goproxy
+	URL string `yaml:"url"`
+	// Regex and JSONPath extract versions for http-index
+	Regex    string `yaml:"regex"`
+	JSONPath string `yaml:"jsonpath"`
+	// VersionRegex extracts versions from tags once prefix is removed, for
+	// all list types (e.g. `^kustomize/v(?P<version>.+)$`)
+	VersionRegex string `yaml:"version_regex"`
+	// TokenEnv is a shortcut for a github, gitlab or gitea token auth;
+	// ignored when Auth is set
+	TokenEnv string    `yaml:"token_env"`
+	Auth     auth.Auth `yaml:"auth"`
+	Versions []string
+}
+
+// options holds dependencies shared by listers
+type options struct {
+	client      *http.Client
+	credentials *auth.Resolver
+	validators  *httpcache.Cache
+}
+
+// Option configures listers returned by Factory
+type Option func(*options)
+
+// WithClient sets the HTTP client used by listers
+func WithClient(c *http.Client) Option {
+	return func(o *options) {
+		o.client = c
+	}
+}
+
+// WithCredentials sets the resolver used to find credentials
+func WithCredentials(r *auth.Resolver) Option {
+	return func(o *options) {
+		o.credentials = r
+	}
+}
+
+// WithValidators sets the cache used to send conditional requests
+func WithValidators(c *httpcache.Cache) Option {
+	return func(o *options) {
+		o.validators = c
+	}
+}
+
+func newOptions(o ...Option) options {
+	opts := options{
+		client: http.DefaultClient,
+	}
+	for _, f := range o {
+		f(&opts)
+	}
+
+	return opts
 }
 
 // Factory returns instances that comply to Lister interface
-func (l List) Factory() Lister {
+func (l List) Factory(o ...Option) Lister {
+	lister := l.lister(newOptions(o...))
+	if lister == nil || l.VersionRegex == "" {
+		return lister
+	}
+
+	return versionExtractor{lister: lister, regex: l.VersionRegex}
+}
+
+// lister returns the lister for the list type
+func (l List) lister(opts options) Lister {
+
 	switch l.Type {
 	case "github-releases":
+		a := l.Auth
+		if a.IsZero() && l.TokenEnv != "" {
+			a = auth.Auth{Type: auth.TypeGithub, TokenEnv: l.TokenEnv}
+		}
 		return GithubRelease{
 			url:         l.URL,
 			prefix:      l.Prefix,
 			versionFrom: l.VersionFrom,
 			exclude:     l.Exclude,
+			auth:        a,
+			credentials: opts.credentials,
+			client:      opts.client,
+			validators:  opts.validators,
 		}
 	case "gitlab-releases":
+		a := l.Auth
+		if a.IsZero() && l.TokenEnv != "" {
+			a = auth.Auth{Type: auth.TypeGitlab, TokenEnv: l.TokenEnv}
+		}
 		return GitlabRelease{
 			url:         l.URL,
 			prefix:      l.Prefix,
 			versionFrom: l.VersionFrom,
 			exclude:     l.Exclude,
-			tokenEnv:    l.TokenEnv,
+			auth:        a,
+			credentials: opts.credentials,
+			client:      opts.client,
+			validators:  opts.validators,
+		}
+	case "gitea-releases":
+		a := l.Auth
+		if a.IsZero() && l.TokenEnv != "" {
+			a = auth.Auth{Type: auth.TypeGithub, TokenEnv: l.TokenEnv}
+		}
+		return GiteaRelease{
+			url:         l.URL,
+			prefix:      l.Prefix,
+			versionFrom: l.VersionFrom,
+			exclude:     l.Exclude,
+			auth:        a,
+			credentials: opts.credentials,
+			client:      opts.client,
+		}
+	case "http-index":
+		return HTTPIndex{
+			url:         l.URL,
+			regex:       l.Regex,
+			jsonPath:    l.JSONPath,
+			prefix:      l.Prefix,
+			exclude:     l.Exclude,
+			auth:        l.Auth,
+			credentials: opts.credentials,
+			client:      opts.client,
+		}
+	case "git-tags":
+		return GitTags{
+			url:         l.URL,
+			prefix:      l.Prefix,
+			exclude:     l.Exclude,
+			auth:        l.Auth,
+			credentials: opts.credentials,
+			client:      opts.client,
+		}
+	case "oci-tags":
+		return OCITags{
+			url:         l.URL,
+			prefix:      l.Prefix,
+			exclude:     l.Exclude,
+			auth:        l.Auth,
+			credentials: opts.credentials,
+			client:      opts.client,
+		}
+	case "goproxy":
+		return GoProxy{
+			module:      l.URL,
+			prefix:      l.Prefix,
+			exclude:     l.Exclude,
+			auth:        l.Auth,
+			credentials: opts.credentials,
+			client:      opts.client,
 		}
 	case "static":
 		return Static{
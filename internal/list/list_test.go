@@ -0,0 +1,44 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestList_Factory_canceled(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer ts.Close()
+
+	lists := []List{
+		{Type: "github-releases", URL: "https://github.com/owner/repo"},
+		{Type: "gitlab-releases", URL: "https://gitlab.com/owner/repo"},
+		{Type: "gitea-releases", URL: ts.URL + "/owner/repo"},
+		{Type: "http-index", URL: ts.URL + "/releases/", Regex: ".*"},
+		{Type: "git-tags", URL: ts.URL + "/repo.git"},
+		{Type: "goproxy", URL: "example.org/tool"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, l := range lists {
+		t.Run(l.Type, func(t *testing.T) {
+			_, err := l.Factory(WithClient(ts.Client())).Get(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("Get() error = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+
+	if n := requests.Load(); n != 0 {
+		t.Errorf("%d requests sent with a canceled context", n)
+	}
+}
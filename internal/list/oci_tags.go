@@ -0,0 +1,89 @@
+package list
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	gov "github.com/hashicorp/go-version"
+	"github.com/rs/zerolog"
+
+	"github.com/devops-works/binenv/internal/auth"
+	"github.com/devops-works/binenv/internal/oci"
+)
+
+// OCITags contains what is required to get a list of tags from an OCI
+// registry repository
+type OCITags struct {
+	url         string
+	prefix      string
+	exclude     string
+	auth        auth.Auth
+	credentials *auth.Resolver
+	client      *http.Client
+}
+
+// Get returns a list of available versions
+// Only tags parsing as versions (once prefix is removed) are returned, so
+// tags like latest or signatures are ignored.
+func (o OCITags) Get(ctx context.Context) ([]Release, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "OCITags.Get").Logger()
+
+	ref, err := oci.ParseReference(o.url)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := oci.NewClient(o.client, func(host string) (string, string, error) {
+		c, err := o.credentials.Resolve(o.auth, host)
+		if err != nil {
+			return "", "", err
+		}
+		username, password := c.Basic()
+		return username, password, nil
+	})
+
+	logger.Debug().Msgf("fetching tags for %s", ref)
+
+	// Like other listers, do not abort on the update timeout: token
+	// challenges and pagination can take a few round trips
+	tags, err := registry.Tags(context.WithoutCancel(ctx), ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var re *regexp.Regexp
+	if o.exclude != "" {
+		re, err = regexp.Compile(o.exclude)
+		if err != nil {
+			logger.Error().Err(err).Msgf("error compiling regular expression %q", o.exclude)
+			return nil, err
+		}
+	}
+
+	versions := []string{}
+
+	for _, sv := range tags {
+		if re != nil && re.Match([]byte(sv)) {
+			logger.Debug().Msgf("skipping version %q excluded by exclude regexp %q", sv, o.exclude)
+			continue
+		}
+
+		if o.prefix != "" {
+			if !strings.HasPrefix(sv, o.prefix) {
+				continue
+			}
+			sv = strings.TrimPrefix(sv, o.prefix)
+		}
+
+		if _, err := gov.NewVersion(sv); err != nil {
+			logger.Debug().Msgf("skipping tag %q which is not a version", sv)
+			continue
+		}
+
+		versions = append(versions, sv)
+	}
+
+	return newReleases(versions), nil
+}
@@ -0,0 +1,94 @@
+package list
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/devops-works/binenv/internal/auth"
+	"github.com/devops-works/binenv/internal/oci/ocitest"
+)
+
+func TestOCITags_Get(t *testing.T) {
+	reg := ocitest.NewRegistry()
+	defer reg.Close()
+
+	// Force a few pages
+	reg.PageSize = 2
+
+	for _, tag := range []string{"v1.0.0", "v1.1.0", "v1.2.0-rc1", "latest", "sha256-abcdef.sig", "v2.0.0"} {
+		reg.Push("org/tool", tag, map[string][]byte{"tool": []byte(tag)})
+	}
+
+	tests := []struct {
+		name     string
+		list     List
+		username string
+		password string
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name: "all pages",
+			list: List{URL: "/org/tool", Prefix: "v"},
+			want: []string{"1.0.0", "1.1.0", "1.2.0-rc1", "2.0.0"},
+		},
+		{
+			name: "exclude",
+			list: List{URL: "/org/tool:ignored", Prefix: "v", Exclude: "-rc"},
+			want: []string{"1.0.0", "1.1.0", "2.0.0"},
+		},
+		{
+			name: "no prefix",
+			list: List{URL: "/org/tool"},
+			want: []string{"v1.0.0", "v1.1.0", "v1.2.0-rc1", "v2.0.0"},
+		},
+		{
+			name:     "credentials",
+			list:     List{URL: "/org/tool", Prefix: "v", Auth: auth.Auth{Type: auth.TypeBasic, Username: "robot", PasswordEnv: "BINENV_TEST_OCI_PASSWORD"}},
+			username: "robot",
+			password: "registry-password",
+			want:     []string{"1.0.0", "1.1.0", "1.2.0-rc1", "2.0.0"},
+		},
+		{
+			name:     "missing credentials",
+			list:     List{URL: "/org/tool"},
+			username: "robot",
+			password: "registry-password",
+			wantErr:  true,
+		},
+		{
+			name:    "unknown repository",
+			list:    List{URL: "/org/other"},
+			wantErr: true,
+		},
+	}
+
+	t.Setenv("BINENV_TEST_OCI_PASSWORD", "registry-password")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg.Username, reg.Password = tt.username, tt.password
+
+			l := tt.list
+			l.Type = "oci-tags"
+			l.URL = reg.URL + l.URL
+
+			got, err := l.Factory(WithClient(reg.Client())).Get(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OCITags.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(Versions(got), tt.want) {
+				t.Errorf("OCITags.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOCITags_Get_invalidReference(t *testing.T) {
+	_, err := List{Type: "oci-tags", URL: "registry.example.org/"}.Factory().Get(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid OCI reference") {
+		t.Errorf("OCITags.Get() error = %v, want invalid reference", err)
+	}
+}
@@ -0,0 +1,43 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RateLimitError is returned when a source rate limit is reached or close
+// It wraps ErrGithubRateLimited, ErrGithubRateLimitClose,
+// ErrGitlabRateLimited or ErrGitlabRateLimitClose, and tells when the quota
+// is restored so callers can wait instead of giving up.
+// When the rate limit is only close, listers return it along with releases.
+type RateLimitError struct {
+	Err       error
+	Source    string
+	Remaining int
+	Limit     int
+	Reset     time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Remaining == 0 {
+		return fmt.Sprintf("%v: rate limited by %s; please retry after %s", e.Err, e.Source, e.Reset)
+	}
+	return fmt.Sprintf("%v: remaining %d of %d; please retry after %s", e.Err, e.Remaining, e.Limit, e.Reset)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// Exhausted returns true when the quota is exhausted, and false when it is
+// only close to be, in which case the response was still served
+func (e *RateLimitError) Exhausted() bool {
+	return !errors.Is(e.Err, ErrGithubRateLimitClose) && !errors.Is(e.Err, ErrGitlabRateLimitClose)
+}
+
+// rateLimitClose returns true if err only tells a rate limit is close
+func rateLimitClose(err error) bool {
+	rle := &RateLimitError{}
+	return errors.As(err, &rle) && !rle.Exhausted()
+}
@@ -10,6 +10,6 @@ type Static struct {
 }
 
 // Get returns a list of available versions
-func (s Static) Get(ctx context.Context) ([]string, error) {
-	return s.versions, nil
+func (s Static) Get(ctx context.Context) ([]Release, error) {
+	return newReleases(s.versions), nil
 }
@@ -0,0 +1,155 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// ErrSkipped is returned by List.Version for tags that are not listed
+var ErrSkipped = errors.New("skipped")
+
+// versionExtractor extracts versions from releases found by another lister
+// using the list version_regex
+type versionExtractor struct {
+	lister Lister
+	regex  string
+}
+
+// Get returns releases whose version matches the regex, with their version
+// set to the extracted one
+func (v versionExtractor) Get(ctx context.Context) ([]Release, error) {
+	releases, err := v.lister.Get(ctx)
+	return v.extract(ctx, releases, err)
+}
+
+// GetNew returns new releases like the wrapped lister, if it is incremental
+func (v versionExtractor) GetNew(ctx context.Context, known func(string) bool) ([]Release, error) {
+	inc, ok := v.lister.(IncrementalLister)
+	if !ok {
+		return v.Get(ctx)
+	}
+
+	re, err := regexp.Compile(v.regex)
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := inc.GetNew(ctx, func(version string) bool {
+		extracted, ok := extractVersion(re, version)
+		return ok && known(extracted)
+	})
+	return v.extract(ctx, releases, err)
+}
+
+// extract applies the version regex to releases returned by the wrapped
+// lister along with err
+// Listers can return releases with an error (e.g. when close to a rate
+// limit), so they are kept too.
+func (v versionExtractor) extract(ctx context.Context, releases []Release, err error) ([]Release, error) {
+	if err != nil && len(releases) == 0 {
+		return nil, err
+	}
+
+	extracted, xerr := extractVersions(ctx, v.regex, releases)
+	if xerr != nil {
+		return nil, xerr
+	}
+
+	return extracted, err
+}
+
+// extractVersions applies version regex expr to releases
+// Releases not matching are skipped.
+func extractVersions(ctx context.Context, expr string, releases []Release) ([]Release, error) {
+	logger := zerolog.Ctx(ctx).With().Str("func", "list.extractVersions").Logger()
+
+	if expr == "" {
+		return releases, nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		logger.Error().Err(err).Msgf("error compiling regular expression %q", expr)
+		return nil, err
+	}
+
+	res := []Release{}
+	for _, r := range releases {
+		version, ok := extractVersion(re, r.Version)
+		if !ok {
+			logger.Debug().Msgf("skipping version %q not matching version regexp %q", r.Version, expr)
+			continue
+		}
+		r.Version = version
+		res = append(res, r)
+	}
+
+	return res, nil
+}
+
+// extractVersion returns the version matched by re in s
+func extractVersion(re *regexp.Regexp, s string) (string, bool) {
+	m := re.FindStringSubmatch(s)
+	group := versionGroup(re)
+	if m == nil || m[group] == "" {
+		return "", false
+	}
+
+	return m[group], true
+}
+
+// versionGroup returns the index of the group holding versions in re
+// The group named "version" is used if any, the first group otherwise, and
+// the whole match when there are no groups.
+func versionGroup(re *regexp.Regexp) int {
+	if i := re.SubexpIndex("version"); i > 0 {
+		return i
+	}
+	if re.NumSubexp() > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// Version returns the version listed for tag (or release name, depending on
+// version_from), applying exclude, prefix and version_regex like listers do
+// An error wrapping ErrSkipped tells why a tag is not listed.
+func (l List) Version(tag string) (string, error) {
+	if l.Exclude != "" {
+		re, err := regexp.Compile(l.Exclude)
+		if err != nil {
+			return "", fmt.Errorf("invalid exclude regexp %q: %w", l.Exclude, err)
+		}
+		if re.MatchString(tag) {
+			return "", fmt.Errorf("%w: %q matches exclude regexp %q", ErrSkipped, tag, l.Exclude)
+		}
+	}
+
+	version := tag
+	if l.Prefix != "" {
+		if !strings.HasPrefix(version, l.Prefix) {
+			return "", fmt.Errorf("%w: %q does not start with prefix %q", ErrSkipped, tag, l.Prefix)
+		}
+		version = strings.TrimPrefix(version, l.Prefix)
+	}
+
+	if l.VersionRegex != "" {
+		re, err := regexp.Compile(l.VersionRegex)
+		if err != nil {
+			return "", fmt.Errorf("invalid version regexp %q: %w", l.VersionRegex, err)
+		}
+		extracted, ok := extractVersion(re, version)
+		if !ok {
+			return "", fmt.Errorf("%w: %q does not match version regexp %q", ErrSkipped, version, l.VersionRegex)
+		}
+		version = extracted
+	}
+
+	return version, nil
+}
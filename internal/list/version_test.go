@@ -0,0 +1,98 @@
+package list
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestList_Version(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    List
+		tag     string
+		want    string
+		skipped bool
+	}{
+		{name: "monorepo", list: List{VersionRegex: `^kustomize/v(?P<version>.+)$`}, tag: "kustomize/v5.3.0", want: "5.3.0"},
+		{name: "monorepo other component", list: List{VersionRegex: `^kustomize/v(?P<version>.+)$`}, tag: "api/v0.16.0", skipped: true},
+		{name: "dash prefix", list: List{VersionRegex: `^cli-v(?P<version>[0-9.]+)$`}, tag: "cli-v2.1.0", want: "2.1.0"},
+		{name: "release", list: List{VersionRegex: `release-(?P<version>.*)`}, tag: "release-1.28.3", want: "1.28.3"},
+		{name: "unnamed group", list: List{VersionRegex: `^jq-(.+)$`}, tag: "jq-1.7", want: "1.7"},
+		{name: "prefix then regex", list: List{Prefix: "v", VersionRegex: `^(?P<version>[0-9.]+)(\+.*)?$`}, tag: "v1.2.3+build", want: "1.2.3"},
+		{name: "prefix only", list: List{Prefix: "v"}, tag: "v1.2.3", want: "1.2.3"},
+		{name: "missing prefix", list: List{Prefix: "v"}, tag: "1.2.3", skipped: true},
+		{name: "excluded", list: List{Exclude: "-rc", VersionRegex: `^cli-v(?P<version>.+)$`}, tag: "cli-v2.1.0-rc1", skipped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.list.Version(tt.tag)
+			if errors.Is(err, ErrSkipped) != tt.skipped {
+				t.Fatalf("List.Version() error = %v, skipped %v", err, tt.skipped)
+			}
+			if !tt.skipped && err != nil {
+				t.Fatalf("List.Version() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("List.Version() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestList_Factory_versionRegex(t *testing.T) {
+	l := List{
+		Type:         "static",
+		Versions:     []string{"kustomize/v5.3.0", "api/v0.16.0", "kustomize/v5.2.1"},
+		VersionRegex: `^kustomize/v(?P<version>.+)$`,
+	}
+
+	got, err := l.Factory().Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if want := []string{"5.3.0", "5.2.1"}; !reflect.DeepEqual(Versions(got), want) {
+		t.Errorf("Get() = %v, want %v", Versions(got), want)
+	}
+
+	l.VersionRegex = "("
+	if _, err := l.Factory().Get(context.Background()); err == nil {
+		t.Errorf("Get() accepted an invalid version regexp")
+	}
+}
+
+// closeLister returns releases along with a rate limit close error
+type closeLister []Release
+
+func (c closeLister) Get(ctx context.Context) ([]Release, error) {
+	return c, &RateLimitError{Err: ErrGithubRateLimitClose, Remaining: 2}
+}
+
+func (c closeLister) GetNew(ctx context.Context, known func(string) bool) ([]Release, error) {
+	return c.Get(ctx)
+}
+
+func TestVersionExtractor_rateLimitClose(t *testing.T) {
+	v := versionExtractor{
+		lister: closeLister{{Version: "kustomize/v5.3.0"}, {Version: "api/v0.16.0"}},
+		regex:  `^kustomize/v(?P<version>.+)$`,
+	}
+
+	got, err := v.Get(context.Background())
+	if !errors.Is(err, ErrGithubRateLimitClose) {
+		t.Errorf("Get() error = %v, want %v", err, ErrGithubRateLimitClose)
+	}
+	if want := []string{"5.3.0"}; !reflect.DeepEqual(Versions(got), want) {
+		t.Errorf("Get() = %v, want %v", Versions(got), want)
+	}
+
+	got, err = v.GetNew(context.Background(), func(string) bool { return false })
+	if !errors.Is(err, ErrGithubRateLimitClose) {
+		t.Errorf("GetNew() error = %v, want %v", err, ErrGithubRateLimitClose)
+	}
+	if want := []string{"5.3.0"}; !reflect.DeepEqual(Versions(got), want) {
+		t.Errorf("GetNew() = %v, want %v", Versions(got), want)
+	}
+}
@@ -0,0 +1,382 @@
+// Package oci implements the subset of the OCI distribution API binenv needs
+// to fetch artifacts (e.g. pushed with ORAS) and list tags
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Media types
+const (
+	MediaTypeImageIndex         = "application/vnd.oci.image.index.v1+json"
+	MediaTypeImageManifest      = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeArtifactManifest   = "application/vnd.oci.artifact.manifest.v1+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// AnnotationTitle holds the file name of ORAS pushed layers
+	AnnotationTitle = "org.opencontainers.image.title"
+)
+
+// ErrNoMatchingManifest is returned when an index has no manifest for the
+// requested platform
+var ErrNoMatchingManifest = errors.New("no matching manifest")
+
+// Descriptor describes content in a registry
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *Platform         `json:"platform,omitempty"`
+}
+
+// Title returns the descriptor title annotation
+func (d Descriptor) Title() string {
+	return d.Annotations[AnnotationTitle]
+}
+
+// Platform describes the platform an index manifest applies to
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Manifest is an image or artifact manifest, or an index
+type Manifest struct {
+	MediaType string       `json:"mediaType"`
+	Manifests []Descriptor `json:"manifests,omitempty"`
+	Layers    []Descriptor `json:"layers,omitempty"`
+	// Blobs is used by (deprecated) artifact manifests
+	Blobs []Descriptor `json:"blobs,omitempty"`
+}
+
+// Files returns manifest layers (or blobs)
+func (m Manifest) Files() []Descriptor {
+	return append(append([]Descriptor{}, m.Layers...), m.Blobs...)
+}
+
+func (m Manifest) isIndex() bool {
+	return m.MediaType == MediaTypeImageIndex ||
+		m.MediaType == MediaTypeDockerManifestList ||
+		(m.MediaType == "" && len(m.Manifests) > 0)
+}
+
+// CredentialsFunc returns the username and password to use for registry
+type CredentialsFunc func(registry string) (string, string, error)
+
+// Client talks to OCI registries
+//
+// Bearer token challenges are handled transparently; tokens are cached per
+// repository.
+type Client struct {
+	client      *http.Client
+	credentials CredentialsFunc
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewClient returns a registry client using c for requests
+// credentials, when set, are used for basic authentication or to get bearer
+// tokens once challenged.
+func NewClient(c *http.Client, credentials CredentialsFunc) *Client {
+	return &Client{
+		client:      c,
+		credentials: credentials,
+		tokens:      make(map[string]string),
+	}
+}
+
+// Resolve returns the manifest for ref
+// When ref points to an index, the manifest for os/arch is returned.
+func (c *Client) Resolve(ctx context.Context, ref Reference, os, arch string) (Manifest, error) {
+	m, err := c.manifest(ctx, ref, ref.Reference)
+	if err != nil {
+		return m, err
+	}
+
+	if !m.isIndex() {
+		return m, nil
+	}
+
+	for _, d := range m.Manifests {
+		if d.Platform != nil && d.Platform.OS == os && d.Platform.Architecture == arch {
+			return c.manifest(ctx, ref, d.Digest)
+		}
+	}
+
+	return Manifest{}, fmt.Errorf("%w for %s/%s in %s", ErrNoMatchingManifest, os, arch, ref)
+}
+
+func (c *Client) manifest(ctx context.Context, ref Reference, reference string) (Manifest, error) {
+	m := Manifest{}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref.url("manifests/"+reference), nil)
+	if err != nil {
+		return m, err
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		MediaTypeImageIndex,
+		MediaTypeImageManifest,
+		MediaTypeArtifactManifest,
+		MediaTypeDockerManifestList,
+		MediaTypeDockerManifest,
+	}, ", "))
+
+	resp, err := c.Do(ref, req)
+	if err != nil {
+		return m, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return m, fmt.Errorf("unable to get manifest for %s: %s", ref, resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&m)
+	if err != nil {
+		return m, fmt.Errorf("invalid manifest for %s: %w", ref, err)
+	}
+	if m.MediaType == "" {
+		m.MediaType = resp.Header.Get("Content-Type")
+	}
+
+	return m, nil
+}
+
+// Tags returns all tags in ref repository, following Link pagination
+func (c *Client) Tags(ctx context.Context, ref Reference) ([]string, error) {
+	tags := []string{}
+	next := ref.url("tags/list")
+
+	for next != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.Do(ref, req)
+		if err != nil {
+			return nil, err
+		}
+
+		page := struct {
+			Tags []string `json:"tags"`
+		}{}
+		if resp.StatusCode == http.StatusOK {
+			err = json.NewDecoder(resp.Body).Decode(&page)
+		} else {
+			err = fmt.Errorf("unable to list tags for %s: %s", ref, resp.Status)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, page.Tags...)
+
+		next, err = nextLink(resp.Header.Get("Link"), req.URL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tags, nil
+}
+
+// nextLink returns the rel="next" target in a Link header, resolved against
+// base, or an empty string
+func nextLink(header string, base *url.URL) (string, error) {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return "", fmt.Errorf("invalid Link header %q: %w", header, err)
+		}
+
+		return base.ResolveReference(u).String(), nil
+	}
+
+	return "", nil
+}
+
+// BlobURL returns the URL for blob digest in ref repository
+func (c *Client) BlobURL(ref Reference, digest string) string {
+	return ref.url("blobs/" + digest)
+}
+
+// Header returns headers required to access ref repository, once
+// authenticated by a previous request
+func (c *Client) Header(ref Reference) map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t, ok := c.tokens[ref.Registry+"/"+ref.Repository]; ok {
+		return map[string]string{"Authorization": t}
+	}
+
+	return map[string]string{}
+}
+
+// Do sends req, authenticating against ref repository if challenged
+func (c *Client) Do(ref Reference, req *http.Request) (*http.Response, error) {
+	for k, v := range c.Header(ref) {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	auth, err := c.authenticate(req.Context(), ref, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.tokens[ref.Registry+"/"+ref.Repository] = auth
+	c.mu.Unlock()
+
+	retry := req.Clone(req.Context())
+	retry.Header.Set("Authorization", auth)
+
+	return c.client.Do(retry)
+}
+
+// authenticate answers challenge and returns the Authorization header value
+// to use
+func (c *Client) authenticate(ctx context.Context, ref Reference, challenge string) (string, error) {
+	scheme, params := parseChallenge(challenge)
+
+	username, password := "", ""
+	if c.credentials != nil {
+		var err error
+		username, password, err = c.credentials(ref.Registry)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if username == "" && password == "" {
+			return "", fmt.Errorf("registry %s requires credentials", ref.Registry)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(username, password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge %q from %s", challenge, ref.Registry)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid token realm %q from %s", params["realm"], ref.Registry)
+	}
+
+	q := realm.Query()
+	if s := params["service"]; s != "" {
+		q.Set("service", s)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" || password != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to get token for %s from %s: %s", ref, realm.Host, resp.Status)
+	}
+
+	tok := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("invalid token response from %s: %w", realm.Host, err)
+	}
+
+	t := tok.Token
+	if t == "" {
+		t = tok.AccessToken
+	}
+	if t == "" {
+		return "", fmt.Errorf("no token returned by %s", realm.Host)
+	}
+
+	return "Bearer " + t, nil
+}
+
+// parseChallenge parses WWW-Authenticate headers like
+// Bearer realm="https://auth.example.org/token",service="registry",scope="..."
+func parseChallenge(h string) (string, map[string]string) {
+	params := make(map[string]string)
+
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(h), " ")
+
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+			continue
+		}
+
+		value, rest, _ = strings.Cut(value, ",")
+		params[key] = strings.TrimSpace(value)
+	}
+
+	return scheme, params
+}
@@ -0,0 +1,31 @@
+package oci
+
+import (
+	"net/url"
+	"testing"
+)
+
+func Test_nextLink(t *testing.T) {
+	base, _ := url.Parse("https://registry.example.org/v2/org/tool/tags/list")
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{`</v2/org/tool/tags/list?n=2&last=b>; rel="next"`, "https://registry.example.org/v2/org/tool/tags/list?n=2&last=b"},
+		{`<https://mirror.example.org/v2/org/tool/tags/list?last=b>; rel="next"`, "https://mirror.example.org/v2/org/tool/tags/list?last=b"},
+		{`</v2/org/tool/tags/list?last=a>; rel="prev", </v2/org/tool/tags/list?last=c>; rel="next"`, "https://registry.example.org/v2/org/tool/tags/list?last=c"},
+		{`</v2/org/tool/tags/list?last=a>; rel="prev"`, ""},
+	}
+	for _, tt := range tests {
+		got, err := nextLink(tt.header, base)
+		if err != nil {
+			t.Errorf("nextLink(%q) error = %v", tt.header, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("nextLink(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
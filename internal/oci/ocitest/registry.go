@@ -0,0 +1,225 @@
+// Package ocitest provides an in-memory OCI registry for tests
+package ocitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/devops-works/binenv/internal/oci"
+)
+
+// Registry is an in-memory registry requiring bearer tokens, like most
+// public registries
+type Registry struct {
+	*httptest.Server
+
+	// Token is the bearer token handed out by the token endpoint
+	Token string
+	// Username and Password, when set, are required to get a token
+	Username string
+	Password string
+	// PageSize, when set, limits the number of tags returned per page
+	PageSize int
+
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string]map[string][]byte // repository -> reference -> content
+}
+
+// NewRegistry starts a registry
+func NewRegistry() *Registry {
+	r := &Registry{
+		Token:     "s3cr3t",
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string]map[string][]byte),
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+
+	return r
+}
+
+// Ref returns the reference for repository:tag in this registry
+func (r *Registry) Ref(repository, tag string) string {
+	return r.URL + "/" + repository + ":" + tag
+}
+
+// Push stores files as an ORAS-like artifact tagged tag, and returns the
+// manifest descriptor
+func (r *Registry) Push(repository, tag string, files map[string][]byte) oci.Descriptor {
+	names := []string{}
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	m := oci.Manifest{MediaType: oci.MediaTypeImageManifest}
+	for _, n := range names {
+		d := r.addBlob(files[n])
+		d.MediaType = "application/octet-stream"
+		d.Annotations = map[string]string{oci.AnnotationTitle: n}
+		m.Layers = append(m.Layers, d)
+	}
+
+	return r.putManifest(repository, tag, m)
+}
+
+// PushIndex tags an index of manifests for platforms ("os/arch")
+func (r *Registry) PushIndex(repository, tag string, manifests map[string]oci.Descriptor) oci.Descriptor {
+	platforms := []string{}
+	for p := range manifests {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+
+	idx := oci.Manifest{MediaType: oci.MediaTypeImageIndex}
+	for _, p := range platforms {
+		d := manifests[p]
+		os, arch, _ := strings.Cut(p, "/")
+		d.Platform = &oci.Platform{OS: os, Architecture: arch}
+		idx.Manifests = append(idx.Manifests, d)
+	}
+
+	return r.putManifest(repository, tag, idx)
+}
+
+func (r *Registry) addBlob(content []byte) oci.Descriptor {
+	sum := sha256.Sum256(content)
+	d := oci.Descriptor{
+		Digest: "sha256:" + hex.EncodeToString(sum[:]),
+		Size:   int64(len(content)),
+	}
+
+	r.mu.Lock()
+	r.blobs[d.Digest] = content
+	r.mu.Unlock()
+
+	return d
+}
+
+func (r *Registry) putManifest(repository, tag string, m oci.Manifest) oci.Descriptor {
+	js, err := json.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+
+	d := r.addBlob(js)
+	d.MediaType = m.MediaType
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.manifests[repository] == nil {
+		r.manifests[repository] = make(map[string][]byte)
+	}
+	r.manifests[repository][tag] = js
+	r.manifests[repository][d.Digest] = js
+
+	return d
+}
+
+func (r *Registry) serve(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		user, pass, _ := req.BasicAuth()
+		if user != r.Username || pass != r.Password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": r.Token})
+		return
+	}
+
+	path, ok := strings.CutPrefix(req.URL.Path, "/v2/")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	repo := path
+	for _, sep := range []string{"/manifests/", "/blobs/", "/tags/"} {
+		if i := strings.Index(path, sep); i >= 0 {
+			repo = path[:i]
+			break
+		}
+	}
+
+	if req.Header.Get("Authorization") != "Bearer "+r.Token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="ocitest",scope="repository:%s:pull"`, r.URL, repo))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(path, "/tags/list"):
+		r.serveTags(w, req, repo)
+	case strings.Contains(path, "/manifests/"):
+		repo, ref, _ := strings.Cut(path, "/manifests/")
+		js, ok := r.manifests[repo][ref]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		m := oci.Manifest{}
+		json.Unmarshal(js, &m)
+		w.Header().Set("Content-Type", m.MediaType)
+		w.Write(js)
+	case strings.Contains(path, "/blobs/"):
+		_, digest, _ := strings.Cut(path, "/blobs/")
+		content, ok := r.blobs[digest]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		http.ServeContent(w, req, "", time.Time{}, bytes.NewReader(content))
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// serveTags lists repo tags, sorted, honoring n and last query parameters and
+// PageSize
+func (r *Registry) serveTags(w http.ResponseWriter, req *http.Request, repo string) {
+	manifests, ok := r.manifests[repo]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	tags := []string{}
+	for ref := range manifests {
+		if !strings.Contains(ref, ":") {
+			tags = append(tags, ref)
+		}
+	}
+	sort.Strings(tags)
+
+	if last := req.URL.Query().Get("last"); last != "" {
+		i := sort.SearchStrings(tags, last)
+		if i < len(tags) && tags[i] == last {
+			i++
+		}
+		tags = tags[i:]
+	}
+
+	n := r.PageSize
+	if q, err := strconv.Atoi(req.URL.Query().Get("n")); err == nil && q > 0 && (n == 0 || q < n) {
+		n = q
+	}
+	if n > 0 && len(tags) > n {
+		tags = tags[:n]
+		w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?n=%d&last=%s>; rel="next"`, repo, n, tags[n-1]))
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"name": repo, "tags": tags})
+}
@@ -0,0 +1,82 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultRegistry is used when a reference has no registry
+const DefaultRegistry = "registry-1.docker.io"
+
+// Reference points to a repository, and optionally a tag or digest, in a
+// registry
+type Reference struct {
+	// Scheme is https unless the reference explicitly used http:// (e.g.
+	// for a local registry)
+	Scheme     string
+	Registry   string
+	Repository string
+	// Tag or digest; may be empty when listing tags
+	Reference string
+}
+
+// ParseReference parses references like ghcr.io/org/tool:1.0.0,
+// oci://ghcr.io/org/tool@sha256:... or http://localhost:5000/tool:1.0.0
+func ParseReference(s string) (Reference, error) {
+	r := Reference{Scheme: "https"}
+
+	switch {
+	case strings.HasPrefix(s, "http://"):
+		r.Scheme = "http"
+		s = strings.TrimPrefix(s, "http://")
+	case strings.HasPrefix(s, "https://"):
+		s = strings.TrimPrefix(s, "https://")
+	default:
+		s = strings.TrimPrefix(s, "oci://")
+	}
+
+	// Registry is the first component if it looks like a host
+	first, rest, ok := strings.Cut(s, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		r.Registry = first
+		s = rest
+	} else {
+		r.Registry = DefaultRegistry
+		if !strings.Contains(s, "/") {
+			s = "library/" + s
+		}
+	}
+
+	if repo, digest, ok := strings.Cut(s, "@"); ok {
+		r.Repository, r.Reference = repo, digest
+	} else if i := strings.LastIndex(s, ":"); i > 0 {
+		r.Repository, r.Reference = s[:i], s[i+1:]
+	} else {
+		r.Repository = s
+	}
+
+	if r.Repository == "" || strings.HasSuffix(r.Repository, "/") {
+		return Reference{}, fmt.Errorf("invalid OCI reference %q", s)
+	}
+
+	return r, nil
+}
+
+// String returns the reference as registry/repository[:tag|@digest]
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	switch {
+	case r.Reference == "":
+	case strings.Contains(r.Reference, ":"):
+		s += "@" + r.Reference
+	default:
+		s += ":" + r.Reference
+	}
+
+	return s
+}
+
+// url returns the registry API URL for path
+func (r Reference) url(path string) string {
+	return fmt.Sprintf("%s://%s/v2/%s/%s", r.Scheme, r.Registry, r.Repository, path)
+}
@@ -0,0 +1,50 @@
+package oci
+
+import "testing"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    Reference
+		wantErr bool
+	}{
+		{ref: "ghcr.io/org/tool:1.0.0", want: Reference{Scheme: "https", Registry: "ghcr.io", Repository: "org/tool", Reference: "1.0.0"}},
+		{ref: "oci://ghcr.io/org/tool:1.0.0", want: Reference{Scheme: "https", Registry: "ghcr.io", Repository: "org/tool", Reference: "1.0.0"}},
+		{ref: "ghcr.io/org/tool@sha256:abcd", want: Reference{Scheme: "https", Registry: "ghcr.io", Repository: "org/tool", Reference: "sha256:abcd"}},
+		{ref: "http://localhost:5000/tool:v1", want: Reference{Scheme: "http", Registry: "localhost:5000", Repository: "tool", Reference: "v1"}},
+		{ref: "localhost/tool", want: Reference{Scheme: "https", Registry: "localhost", Repository: "tool"}},
+		{ref: "alpine:3", want: Reference{Scheme: "https", Registry: DefaultRegistry, Repository: "library/alpine", Reference: "3"}},
+		{ref: "org/tool", want: Reference{Scheme: "https", Registry: DefaultRegistry, Repository: "org/tool"}},
+		{ref: "ghcr.io/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseReference(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.org/token",service="registry.example.org",scope="repository:org/tool:pull"`)
+	if scheme != "Bearer" {
+		t.Errorf("parseChallenge() scheme = %q", scheme)
+	}
+
+	want := map[string]string{
+		"realm":   "https://auth.example.org/token",
+		"service": "registry.example.org",
+		"scope":   "repository:org/tool:pull",
+	}
+	for k, v := range want {
+		if params[k] != v {
+			t.Errorf("parseChallenge() %s = %q, want %q", k, params[k], v)
+		}
+	}
+}
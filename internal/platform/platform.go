@@ -1,7 +1,33 @@
 package platform
 
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
 // Platform lists supported arch/os combinations
 type Platform struct {
 	OS   string `yaml:"os"`
 	Arch string `yaml:"arch"`
 }
+
+// Current returns the running platform
+func Current() Platform {
+	return Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// Parse parses an os/arch string (e.g. linux/amd64)
+func Parse(s string) (Platform, error) {
+	os, arch, ok := strings.Cut(s, "/")
+	if !ok || os == "" || arch == "" || strings.Contains(arch, "/") {
+		return Platform{}, fmt.Errorf("invalid platform %q: must be os/arch (e.g. linux/amd64)", s)
+	}
+
+	return Platform{OS: os, Arch: arch}, nil
+}
+
+// String returns the os/arch representation of p
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
@@ -0,0 +1,234 @@
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	descWidth = 48
+	barWidth  = 24
+)
+
+// Multi displays concurrent transfers
+//
+// On terminals, a line per running transfer is redrawn periodically;
+// completed transfers are printed once and scroll up with other output.
+// Otherwise, a plain line is printed when a transfer starts and when it
+// completes.
+//
+// Other output (e.g. logs) must be written to Multi so it does not garble
+// the display.
+type Multi struct {
+	out io.Writer
+	tty bool
+
+	mu     sync.Mutex
+	lines  []*line
+	drawn  int
+	ticker *time.Ticker
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+type line struct {
+	desc     string
+	total    atomic.Int64
+	current  atomic.Int64
+	finished atomic.Bool
+	start    time.Time
+	m        *Multi
+}
+
+// NewMulti returns a reporter writing to out
+func NewMulti(out io.Writer, tty bool) *Multi {
+	m := &Multi{
+		out: out,
+		tty: tty,
+	}
+
+	if tty {
+		m.ticker = time.NewTicker(150 * time.Millisecond)
+		m.done = make(chan struct{})
+		m.wg.Add(1)
+		go m.loop()
+	}
+
+	return m
+}
+
+func (m *Multi) loop() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.ticker.C:
+			m.mu.Lock()
+			m.redraw()
+			m.mu.Unlock()
+		}
+	}
+}
+
+// Track implements Reporter
+func (m *Multi) Track(desc string, total int64) Tracker {
+	l := &line{
+		desc:  desc,
+		start: time.Now(),
+		m:     m,
+	}
+	l.total.Store(total)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tty {
+		m.lines = append(m.lines, l)
+		m.redraw()
+	} else {
+		fmt.Fprintf(m.out, "%s: started\n", desc)
+	}
+
+	return l
+}
+
+// Write writes p above running transfers
+func (m *Multi) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.tty {
+		return m.out.Write(p)
+	}
+
+	m.clear()
+	n, err := m.out.Write(p)
+	m.redraw()
+
+	return n, err
+}
+
+// Stop draws the final state and stops refreshing the display
+func (m *Multi) Stop() {
+	if !m.tty {
+		return
+	}
+
+	close(m.done)
+	m.ticker.Stop()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.redraw()
+}
+
+// clear erases drawn lines; m.mu must be held
+func (m *Multi) clear() {
+	if m.drawn > 0 {
+		fmt.Fprintf(m.out, "\x1b[%dA\x1b[J", m.drawn)
+		m.drawn = 0
+	}
+}
+
+// redraw draws all lines; m.mu must be held
+func (m *Multi) redraw() {
+	buf := bytes.Buffer{}
+
+	if m.drawn > 0 {
+		fmt.Fprintf(&buf, "\x1b[%dA", m.drawn)
+	}
+
+	// Finished transfers at the top are printed for good
+	for len(m.lines) > 0 && m.lines[0].finished.Load() {
+		fmt.Fprintf(&buf, "\r\x1b[2K%s\n", m.lines[0].String())
+		m.lines = m.lines[1:]
+	}
+
+	for _, l := range m.lines {
+		fmt.Fprintf(&buf, "\r\x1b[2K%s\n", l.String())
+	}
+	buf.WriteString("\x1b[J")
+
+	m.drawn = len(m.lines)
+	m.out.Write(buf.Bytes())
+}
+
+func (l *line) Write(p []byte) (int, error) {
+	l.current.Add(int64(len(p)))
+	return len(p), nil
+}
+
+func (l *line) SetTotal(total int64) {
+	l.total.Store(total)
+}
+
+func (l *line) SetCurrent(current int64) {
+	l.current.Store(current)
+}
+
+func (l *line) Finish() {
+	if l.finished.Swap(true) {
+		return
+	}
+
+	if !l.m.tty {
+		l.m.mu.Lock()
+		defer l.m.mu.Unlock()
+		fmt.Fprintf(l.m.out, "%s: done (%s in %s)\n", l.desc, Size(l.current.Load()), time.Since(l.start).Round(100*time.Millisecond))
+	}
+}
+
+// String renders the line
+func (l *line) String() string {
+	desc := l.desc
+	if len(desc) > descWidth {
+		desc = desc[:descWidth-1] + "…"
+	}
+
+	current, total := l.current.Load(), l.total.Load()
+
+	if l.finished.Load() {
+		return fmt.Sprintf("%-*s done (%s in %s)", descWidth, desc, Size(current), time.Since(l.start).Round(100*time.Millisecond))
+	}
+
+	if total <= 0 {
+		return fmt.Sprintf("%-*s %s", descWidth, desc, Size(current))
+	}
+
+	filled := int(current * barWidth / total)
+	if filled > barWidth {
+		filled = barWidth
+	}
+
+	return fmt.Sprintf("%-*s [%s%s] %3d%% (%s/%s)",
+		descWidth, desc,
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled),
+		current*100/total,
+		Size(current), Size(total),
+	)
+}
+
+// Size returns a human readable size
+func Size(s int64) string {
+	const unit = 1024
+	if s < unit {
+		return fmt.Sprintf("%d B", s)
+	}
+
+	div, exp := int64(unit), 0
+	for n := s / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(s)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,87 @@
+package progress
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMulti_notTerminal(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := NewMulti(buf, false)
+
+	tr := m.Track("tool", 2048)
+	tr.Write(make([]byte, 1024))
+	tr.SetCurrent(2048)
+	m.Write([]byte("a log line\n"))
+	tr.Finish()
+	// Finishing twice prints once
+	tr.Finish()
+	m.Stop()
+
+	got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(got) != 3 {
+		t.Fatalf("Multi output = %q, want 3 lines", got)
+	}
+	if got[0] != "tool: started" || got[1] != "a log line" {
+		t.Errorf("Multi output = %q", got)
+	}
+	if !regexp.MustCompile(`^tool: done \(2\.0 KiB in .+\)$`).MatchString(got[2]) {
+		t.Errorf("Multi output = %q, want completion line", got[2])
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("Multi output contains escape sequences: %q", buf.String())
+	}
+}
+
+func TestMulti_terminal(t *testing.T) {
+	buf := &bytes.Buffer{}
+	m := NewMulti(buf, true)
+
+	first := m.Track("first", 100)
+	second := m.Track("second", -1)
+	first.SetCurrent(50)
+	second.Write(make([]byte, 10))
+
+	m.mu.Lock()
+	if got := first.(*line).String(); !strings.Contains(got, "[============            ]  50% (50 B/100 B)") {
+		t.Errorf("line.String() = %q", got)
+	}
+	if got := second.(*line).String(); !strings.HasSuffix(got, " 10 B") {
+		t.Errorf("line.String() for unknown size = %q", got)
+	}
+	m.mu.Unlock()
+
+	first.Finish()
+	m.Write([]byte("a log line\n"))
+	m.Stop()
+
+	// Finished transfers at the top are not redrawn anymore
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.lines) != 1 || m.lines[0].desc != "second" {
+		t.Errorf("Multi running lines = %d, want second only", len(m.lines))
+	}
+	if !strings.Contains(buf.String(), "a log line\n") {
+		t.Errorf("Multi output = %q, want log line", buf.String())
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := Size(tt.size); got != tt.want {
+			t.Errorf("Size(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package progress reports transfers progress
+package progress
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// Tracker reports progress for a single transfer
+// Bytes written to the tracker are added to the current count.
+type Tracker interface {
+	io.Writer
+	// SetTotal sets the expected size; -1 when unknown
+	SetTotal(total int64)
+	// SetCurrent sets the amount of data transferred so far
+	SetCurrent(current int64)
+	// Finish marks the transfer as complete
+	Finish()
+}
+
+// Reporter creates trackers
+type Reporter interface {
+	Track(desc string, total int64) Tracker
+}
+
+type contextKey struct{}
+
+// WithReporter returns a context carrying r
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext returns the reporter in ctx, or a reporter displaying a
+// single progress bar if none
+func FromContext(ctx context.Context) Reporter {
+	if r, ok := ctx.Value(contextKey{}).(Reporter); ok {
+		return r
+	}
+
+	return Bar{}
+}
+
+// IsTerminal returns true if f is a terminal
+func IsTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return st.Mode()&os.ModeCharDevice != 0
+}
+
+// Bar displays a progress bar per transfer
+type Bar struct{}
+
+// Track implements Reporter
+func (Bar) Track(desc string, total int64) Tracker {
+	return bar{progressbar.DefaultBytes(total, desc)}
+}
+
+type bar struct {
+	*progressbar.ProgressBar
+}
+
+func (b bar) SetTotal(total int64) {
+	if total >= 0 {
+		b.ChangeMax64(total)
+	}
+}
+
+func (b bar) SetCurrent(current int64) {
+	b.Set64(current)
+}
+
+func (b bar) Finish() {
+	b.ProgressBar.Finish()
+}
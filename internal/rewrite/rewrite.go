@@ -0,0 +1,124 @@
+package rewrite
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Rule rewrites URLs starting with Prefix, or matching Regex, using Replace
+//
+// For regex rules, Replace can reference capture groups (e.g. $1 or ${name}).
+type Rule struct {
+	Prefix  string `yaml:"prefix"`
+	Regex   string `yaml:"regex"`
+	Replace string `yaml:"replace"`
+
+	re *regexp.Regexp
+}
+
+// Rules is an ordered list of rules; the first matching rule wins
+type Rules []Rule
+
+// Compile validates rules and compiles regular expressions
+func (r Rules) Compile() error {
+	for i, rule := range r {
+		switch {
+		case rule.Prefix != "" && rule.Regex != "":
+			return fmt.Errorf("rewrite rule %d: prefix and regex are mutually exclusive", i+1)
+		case rule.Prefix == "" && rule.Regex == "":
+			return fmt.Errorf("rewrite rule %d: either prefix or regex is required", i+1)
+		case rule.Regex != "":
+			re, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return fmt.Errorf("rewrite rule %d: %w", i+1, err)
+			}
+			r[i].re = re
+		}
+	}
+
+	return nil
+}
+
+// Apply returns the rewritten URL
+// Rules must have been compiled
+func (r Rules) Apply(u string) string {
+	for _, rule := range r {
+		switch {
+		case rule.re != nil:
+			if rule.re.MatchString(u) {
+				return rule.re.ReplaceAllString(u, rule.Replace)
+			}
+		case rule.Prefix != "":
+			if strings.HasPrefix(u, rule.Prefix) {
+				return rule.Replace + strings.TrimPrefix(u, rule.Prefix)
+			}
+		}
+	}
+
+	return u
+}
+
+// credentialHeaders are headers carrying credentials, dropped when a request
+// is rewritten to another host
+var credentialHeaders = []string{"Authorization", "Private-Token", "Job-Token", "Cookie"}
+
+// Transport rewrites requests URLs before handing them to Base
+// Since every request goes through it, redirections are rewritten too.
+// Rewrites are logged using the request context logger, or Logger when the
+// context has none.
+// Credentials were set for the original host, so they are dropped when the
+// host changes; Authorize, if set, then sets those for the new host. Auth
+// configured for the distribution is not used for the new host.
+type Transport struct {
+	Rules     Rules
+	Base      http.RoundTripper
+	Logger    *zerolog.Logger
+	Authorize func(*http.Request) error
+}
+
+// RoundTrip implements http.RoundTripper
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	orig := req.URL.String()
+	rewritten := t.Rules.Apply(orig)
+	if rewritten == orig {
+		return base.RoundTrip(req)
+	}
+
+	u, err := url.Parse(rewritten)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rewritten URL %q for %q: %w", rewritten, orig, err)
+	}
+
+	logger := zerolog.Ctx(req.Context())
+	if logger.GetLevel() == zerolog.Disabled && t.Logger != nil {
+		logger = t.Logger
+	}
+	logger.Debug().Msgf("rewriting %s to %s", orig, rewritten)
+
+	r := req.Clone(req.Context())
+	r.URL = u
+	r.Host = ""
+
+	if u.Host != req.URL.Host {
+		for _, h := range credentialHeaders {
+			r.Header.Del(h)
+		}
+		if t.Authorize != nil {
+			if err := t.Authorize(r); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return base.RoundTrip(r)
+}
@@ -0,0 +1,143 @@
+package rewrite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRules_Apply(t *testing.T) {
+	rules := Rules{
+		{Prefix: "https://github.com/", Replace: "https://artifactory.example.org/github/"},
+		{Regex: `^https://api\.github\.com/repos/([^/]+)/([^/]+)/releases`, Replace: "https://artifactory.example.org/api/github/$1/$2/releases"},
+		{Prefix: "https://", Replace: "https://artifactory.example.org/generic/"},
+	}
+	if err := rules.Compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "prefix",
+			url:  "https://github.com/nektos/act/releases/download/v0.2.88/act_Linux_x86_64.tar.gz",
+			want: "https://artifactory.example.org/github/nektos/act/releases/download/v0.2.88/act_Linux_x86_64.tar.gz",
+		},
+		{
+			name: "regex",
+			url:  "https://api.github.com/repos/nektos/act/releases?page=2",
+			want: "https://artifactory.example.org/api/github/nektos/act/releases?page=2",
+		},
+		{
+			name: "first match wins",
+			url:  "https://dl.k8s.io/release/stable.txt",
+			want: "https://artifactory.example.org/generic/dl.k8s.io/release/stable.txt",
+		},
+		{
+			name: "no match",
+			url:  "http://example.org/foo",
+			want: "http://example.org/foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Apply(tt.url); got != tt.want {
+				t.Errorf("Rules.Apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRules_Compile(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   Rules
+		wantErr bool
+	}{
+		{name: "valid", rules: Rules{{Prefix: "a", Replace: "b"}, {Regex: "^a(.*)$", Replace: "b$1"}}},
+		{name: "both", rules: Rules{{Prefix: "a", Regex: "a", Replace: "b"}}, wantErr: true},
+		{name: "none", rules: Rules{{Replace: "b"}}, wantErr: true},
+		{name: "invalid regex", rules: Rules{{Regex: "(", Replace: "b"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Compile(); (err != nil) != tt.wantErr {
+				t.Errorf("Rules.Compile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransport_RoundTrip_credentials(t *testing.T) {
+	var got http.Header
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer mirror.Close()
+
+	tests := []struct {
+		name      string
+		url       string
+		rules     Rules
+		authorize func(*http.Request) error
+		want      string
+	}{
+		{
+			name:  "other host",
+			url:   "https://github.example.org/releases",
+			rules: Rules{{Prefix: "https://github.example.org/", Replace: mirror.URL + "/github/"}},
+			want:  "",
+		},
+		{
+			name:  "other host with credentials",
+			url:   "https://github.example.org/releases",
+			rules: Rules{{Prefix: "https://github.example.org/", Replace: mirror.URL + "/github/"}},
+			authorize: func(r *http.Request) error {
+				r.Header.Set("Authorization", "Bearer mirror")
+				return nil
+			},
+			want: "Bearer mirror",
+		},
+		{
+			name:  "same host",
+			url:   mirror.URL + "/releases",
+			rules: Rules{{Prefix: mirror.URL + "/", Replace: mirror.URL + "/github/"}},
+			want:  "token secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Compile(); err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: Transport{Rules: tt.rules, Authorize: tt.authorize}}
+
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "token secret")
+			req.Header.Set("PRIVATE-TOKEN", "secret")
+
+			got = nil
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if a := got.Get("Authorization"); a != tt.want {
+				t.Errorf("Authorization = %q, want %q", a, tt.want)
+			}
+			if tt.want != "token secret" && got.Get("PRIVATE-TOKEN") != "" {
+				t.Errorf("PRIVATE-TOKEN sent to rewritten host")
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store is a content-addressed artifacts store
+//
+// Blobs are stored by content hash under blobs/sha256, and indexed by the URL
+// they have been downloaded from under index/. Identical URLs (e.g. age and
+// age-keygen use the same tarball) or identical content share the same blob.
+type Store struct {
+	dir  string
+	mode os.FileMode
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Entry describes an artifact in the store
+type Entry struct {
+	URL     string    `json:"url"`
+	Digest  string    `json:"digest"`
+	Size    int64     `json:"size"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// New returns a store rooted in dir
+// mode is used when creating directories
+func New(dir string, mode os.FileMode) *Store {
+	return &Store{
+		dir:   dir,
+		mode:  mode,
+		locks: make(map[string]*sync.Mutex),
+	}
+}
+
+// Lock prevents concurrent downloads of url until the returned function is
+// called
+func (s *Store) Lock(url string) func() {
+	s.mu.Lock()
+	l, ok := s.locks[url]
+	if !ok {
+		l = &sync.Mutex{}
+		s.locks[url] = l
+	}
+	s.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
+
+// Dir returns the store root directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Lookup returns the blob path for url if present in the store
+func (s *Store) Lookup(url string) (string, bool) {
+	e, ok := s.Stat(url)
+	if !ok {
+		return "", false
+	}
+
+	return s.blobPath(e.Digest), true
+}
+
+// Stat returns the entry for url if present in the store
+func (s *Store) Stat(url string) (Entry, bool) {
+	e, err := s.readEntry(s.indexPath(url))
+	if err != nil {
+		return Entry{}, false
+	}
+
+	st, err := os.Stat(s.blobPath(e.Digest))
+	if err != nil || st.Size() != e.Size {
+		return Entry{}, false
+	}
+
+	return e, true
+}
+
+// Open opens the blob for entry e
+func (s *Store) Open(e Entry) (*os.File, error) {
+	return os.Open(s.blobPath(e.Digest))
+}
+
+// TempFile creates a temporary file in the store
+// Downloads should be written there so Put can move them atomically
+func (s *Store) TempFile(pattern string) (*os.File, error) {
+	dir := filepath.Join(s.dir, "tmp")
+	if err := os.MkdirAll(dir, s.mode); err != nil {
+		return nil, err
+	}
+
+	return os.CreateTemp(dir, pattern)
+}
+
+// PartialFile opens the partial download file for url
+// The file is not truncated, so an interrupted download can be resumed
+func (s *Store) PartialFile(url string) (*os.File, error) {
+	dir := filepath.Join(s.dir, "tmp")
+	if err := os.MkdirAll(dir, s.mode); err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	return os.OpenFile(filepath.Join(dir, hex.EncodeToString(sum[:])+".part"), os.O_RDWR|os.O_CREATE, s.mode&0666)
+}
+
+// Put moves src to the store and indexes it for url
+// The returned path is the blob location
+func (s *Store) Put(url, src string) (string, error) {
+	fd, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	size, err := io.Copy(h, fd)
+	fd.Close()
+	if err != nil {
+		return "", err
+	}
+
+	e := Entry{
+		URL:     url,
+		Digest:  "sha256:" + hex.EncodeToString(h.Sum(nil)),
+		Size:    size,
+		Fetched: time.Now(),
+	}
+
+	return s.commit(e, src)
+}
+
+// Import adds the content read from r to the store using entry e (e.g. when
+// seeding the store from a bundle)
+// Content is checked against e size and digest.
+func (s *Store) Import(e Entry, r io.Reader) error {
+	algo, want, _ := strings.Cut(e.Digest, ":")
+	if algo != "sha256" {
+		return fmt.Errorf("unsupported digest %q for %s", e.Digest, e.URL)
+	}
+
+	tmp, err := s.TempFile("import")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != want || size != e.Size {
+		return fmt.Errorf("content for %s does not match digest %s", e.URL, e.Digest)
+	}
+
+	_, err = s.commit(e, tmp.Name())
+	return err
+}
+
+// Add indexes entry e, whose content is already in the store (e.g. when
+// importing several entries sharing the same content)
+func (s *Store) Add(e Entry) error {
+	st, err := os.Stat(s.blobPath(e.Digest))
+	if err != nil {
+		return err
+	}
+	if st.Size() != e.Size {
+		return fmt.Errorf("stored content for %s does not match digest %s", e.URL, e.Digest)
+	}
+
+	return s.writeEntry(e)
+}
+
+// commit moves src to its blob location and indexes it
+func (s *Store) commit(e Entry, src string) (string, error) {
+	blob := s.blobPath(e.Digest)
+	if err := os.MkdirAll(filepath.Dir(blob), s.mode); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(src, s.mode&0666); err != nil {
+		return "", err
+	}
+	if err := os.Rename(src, blob); err != nil {
+		return "", err
+	}
+
+	if err := s.writeEntry(e); err != nil {
+		return "", err
+	}
+
+	return blob, nil
+}
+
+// List returns all indexed artifacts, sorted by URL
+func (s *Store) List() ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "index", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, f := range files {
+		e, err := s.readEntry(f)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].URL < entries[j].URL
+	})
+
+	return entries, nil
+}
+
+// Size returns the disk space used by the store
+func (s *Store) Size() (int64, error) {
+	var size int64
+
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
+}
+
+// Clean removes all artifacts from the store
+func (s *Store) Clean() error {
+	for _, d := range []string{"index", "blobs", "tmp"} {
+		if err := os.RemoveAll(filepath.Join(s.dir, d)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) indexPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(s.dir, "index", hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *Store) blobPath(digest string) string {
+	algo, hash, _ := strings.Cut(digest, ":")
+	return filepath.Join(s.dir, "blobs", algo, hash)
+}
+
+func (s *Store) readEntry(file string) (Entry, error) {
+	e := Entry{}
+
+	js, err := os.ReadFile(file)
+	if err != nil {
+		return e, err
+	}
+
+	err = json.Unmarshal(js, &e)
+	if err != nil {
+		return e, fmt.Errorf("unable to read store index %s: %w", file, err)
+	}
+
+	return e, nil
+}
+
+func (s *Store) writeEntry(e Entry) error {
+	js, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	index := s.indexPath(e.URL)
+	if err := os.MkdirAll(filepath.Dir(index), s.mode); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(index), "index")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(js)
+	tmp.Close()
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), s.mode&0666); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), index)
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStore_PutLookup(t *testing.T) {
+	s := New(t.TempDir(), 0750)
+
+	put := func(url, content string) string {
+		t.Helper()
+
+		f, err := s.TempFile("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(content)
+		f.Close()
+
+		blob, err := s.Put(url, f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return blob
+	}
+
+	if _, ok := s.Lookup("https://example.org/a.tgz"); ok {
+		t.Errorf("Store.Lookup() found unknown URL")
+	}
+
+	a := put("https://example.org/a.tgz", "same content")
+	b := put("https://example.org/b.tgz", "same content")
+	if a != b {
+		t.Errorf("Store.Put() = %v, want shared blob %v", b, a)
+	}
+
+	got, ok := s.Lookup("https://example.org/a.tgz")
+	if !ok || got != a {
+		t.Errorf("Store.Lookup() = %v, %v, want %v, true", got, ok, a)
+	}
+
+	entries, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].URL != "https://example.org/a.tgz" || entries[0].Size != 12 {
+		t.Errorf("Store.List() = %v", entries)
+	}
+
+	// A missing blob must not be returned
+	os.Remove(a)
+	if _, ok := s.Lookup("https://example.org/a.tgz"); ok {
+		t.Errorf("Store.Lookup() returned a missing blob")
+	}
+
+	if err := s.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(s.Dir(), "*")); len(files) != 0 {
+		t.Errorf("Store.Clean() left %v", files)
+	}
+}
+
+func TestStore_Import(t *testing.T) {
+	src := New(t.TempDir(), 0750)
+	dst := New(t.TempDir(), 0750)
+
+	f, err := src.TempFile("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("some content")
+	f.Close()
+	if _, err := src.Put("https://example.org/a.tgz", f.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	e, ok := src.Stat("https://example.org/a.tgz")
+	if !ok {
+		t.Fatal("Store.Stat() did not find entry")
+	}
+
+	if err := dst.Import(e, strings.NewReader("other content")); err == nil {
+		t.Errorf("Store.Import() accepted content not matching digest")
+	}
+
+	blob, err := src.Open(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blob.Close()
+
+	if err := dst.Import(e, blob); err != nil {
+		t.Fatalf("Store.Import() error = %v", err)
+	}
+
+	got, ok := dst.Stat("https://example.org/a.tgz")
+	if !ok || got != e {
+		t.Errorf("Store.Stat() = %v, %v, want %v, true", got, ok, e)
+	}
+
+	// Entries sharing content already in the store are only indexed
+	e.URL = "https://example.org/b.tgz"
+	if err := dst.Add(e); err != nil {
+		t.Fatalf("Store.Add() error = %v", err)
+	}
+	if _, ok := dst.Stat("https://example.org/b.tgz"); !ok {
+		t.Errorf("Store.Add() did not index entry")
+	}
+
+	e.Digest = "sha256:" + strings.Repeat("0", 64)
+	if err := dst.Add(e); err == nil {
+		t.Errorf("Store.Add() indexed an entry without content")
+	}
+}
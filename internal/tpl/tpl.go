@@ -3,12 +3,12 @@ package tpl
 import (
 	"bytes"
 	"regexp"
-	"runtime"
 	"strings"
 	"sync"
 	"text/template"
 
 	"github.com/devops-works/binenv/internal/mapping"
+	"github.com/devops-works/binenv/internal/platform"
 	gov "github.com/hashicorp/go-version"
 )
 
@@ -24,14 +24,19 @@ type Args struct {
 	ExeExtension string
 }
 
-// New returns populated template Args
+// New returns populated template Args for the running platform
 func New(v string, mapper mapping.Mapper) Args {
-	rarch := runtime.GOARCH
-	ros := runtime.GOOS
+	return NewFor(v, mapper, platform.Current())
+}
+
+// NewFor returns populated template Args for platform p
+func NewFor(v string, mapper mapping.Mapper, p platform.Platform) Args {
+	rarch := p.Arch
+	ros := p.OS
 
 	if mapper != nil {
-		rarch = mapper.MustInterpolate(runtime.GOARCH)
-		ros = mapper.MustInterpolate(runtime.GOOS)
+		rarch = mapper.MustInterpolate(p.Arch)
+		ros = mapper.MustInterpolate(p.OS)
 		// fmt.Printf("remapping arch %s to %s\n", runtime.GOARCH, rarch)
 		// fmt.Printf("remapping os %s to %s\n", runtime.GOOS, ros)
 	}
@@ -19,11 +19,18 @@ fi
 
 make
 
+# Start from the cache with release metadata when there is one
+if [ -f distributions/cache.v2.json ]; then
+    cp distributions/cache.v2.json distributions/cache.json
+fi
+
 echo "Updating the cache (4 threads)"
 ./bin/binenv update --cachedir ./distributions --confdir ./distributions -f -c4
 
 echo "Importing resulting cache"
-jq '.' < distributions/cache.json > distributions/cache.json.tmp
-mv distributions/cache.json.tmp distributions/cache.json
+jq '.' < distributions/cache.json > distributions/cache.v2.json
+
+# binenv releases before cache schema 2 only read a map of versions
+jq '.distributions | map_values(map(.version))' < distributions/cache.v2.json > distributions/cache.json
 
 echo "Please test the cache using './scripts/validate.sh code'"